@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go"
@@ -13,7 +14,7 @@ import (
 //MODEL_RUNNER_BASE_URL=http://localhost:12434 go run main.go
 func main() {
 	// Docker Model Runner Chat base URL
-	llmURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
+	llmURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
 	model := os.Getenv("MODEL_RUNNER_LLM_CHAT")
 
 	fmt.Println("🤖 Model Runner URL:", llmURL)
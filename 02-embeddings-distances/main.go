@@ -5,6 +5,7 @@ import (
 	"embeddings-demo/rag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -18,11 +19,12 @@ var chunks = []string{
 }
 
 // MODEL_RUNNER_BASE_URL=http://localhost:12434 go run main.go
+// MODEL_RUNNER_LLM_EMBEDDINGS is optional (default: ai/mxbai-embed-large)
 func main() {
 	ctx := context.Background()
 
-	llmURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
-	embeddingsModel := "ai/mxbai-embed-large"
+	llmURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
+	embeddingsModel := getEnv("MODEL_RUNNER_LLM_EMBEDDINGS", "ai/mxbai-embed-large")
 
 	client := openai.NewClient(
 		option.WithBaseURL(llmURL),
@@ -70,3 +72,11 @@ func main() {
 		}
 	}
 }
+
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -19,11 +20,12 @@ var chunks = []string{
 }
 
 // MODEL_RUNNER_BASE_URL=http://localhost:12434 go run main.go
+// MODEL_RUNNER_LLM_EMBEDDINGS is optional (default: ai/mxbai-embed-large)
 func main() {
 	ctx := context.Background()
 
-	llmURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
-	embeddingsModel := "ai/mxbai-embed-large"
+	llmURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
+	embeddingsModel := getEnv("MODEL_RUNNER_LLM_EMBEDDINGS", "ai/mxbai-embed-large")
 
 	client := openai.NewClient(
 		option.WithBaseURL(llmURL),
@@ -33,9 +35,7 @@ func main() {
 	// -------------------------------------------------
 	// Create a vector store
 	// -------------------------------------------------
-	store := rag.MemoryVectorStore{
-		Records: make(map[string]rag.VectorRecord),
-	}
+	store := rag.NewMemoryVectorStore()
 
 	// -------------------------------------------------
 	// Create and save the embeddings from the chunks
@@ -52,19 +52,25 @@ func main() {
 
 		if err != nil {
 			fmt.Println(err)
-		} else {
-			_, errSave := store.Save(rag.VectorRecord{
-				Prompt:    chunk,
-				Embedding: embeddingsResponse.Data[0].Embedding,
-			})
-
-			if errSave != nil {
-				fmt.Println("😡:", errSave)
-			}
+			continue
+		}
+		embedding, err := rag.FirstEmbedding(embeddingsResponse)
+		if err != nil {
+			fmt.Println("😡:", err)
+			continue
+		}
+
+		_, errSave := store.Save(rag.VectorRecord{
+			Prompt:    chunk,
+			Embedding: embedding,
+		})
+
+		if errSave != nil {
+			fmt.Println("😡:", errSave)
 		}
 	}
 
-	fmt.Println("✋", "Embeddings created, total of records", len(store.Records))
+	fmt.Println("✋", "Embeddings created, total of records", store.Len())
 	fmt.Println()
 
 	// -------------------------------------------------
@@ -92,25 +98,47 @@ func main() {
 	// -------------------------------------------------
 	// Create a vector record from the user embedding
 	// -------------------------------------------------
+	embedding, err := rag.FirstEmbedding(embeddingsResponse)
+	if err != nil {
+		log.Fatal("😡:", err)
+	}
 	embeddingFromUserQuestion := rag.VectorRecord{
 		//Prompt:    userQuestion,
-		Embedding: embeddingsResponse.Data[0].Embedding,
+		Embedding: embedding,
 	}
 
 	//similarities, _  := store.SearchSimilarities(embeddingFromUserQuestion, 0.6)
 
-	similarities, _ := store.SearchTopNSimilarities(embeddingFromUserQuestion, 0.6, 2)
+	// The records below the limit are returned too (Passed is false) to see how close they were
+	similarities, err := store.SearchTopNSimilaritiesWithMisses(embeddingFromUserQuestion, 0.6, 2)
+	if err != nil {
+		log.Fatal("😡:", err)
+	}
 	// if the limit is to near from 1, the risk is to lose the best match
 
 	//documentsContent := "Documents:\n"
 
+	found := 0
 	for _, similarity := range similarities {
+		if !similarity.Passed {
+			fmt.Println("❌ Below the limit, CosineSimilarity:", similarity.CosineSimilarity, "Chunk:", similarity.Prompt)
+			continue
+		}
+		found++
 		fmt.Println("✅ CosineSimilarity:", similarity.CosineSimilarity, "Chunk:", similarity.Prompt)
 		//documentsContent += fmt.Sprintf("<doc>%s</doc>\n", similarity.Prompt)
 		//documentsContent += similarity.Prompt
 	}
 	//documentsContent += "\n"
-	fmt.Println("✋", "Similarities found, total of records", len(similarities))
+	fmt.Println("✋", "Similarities found, total of records", found)
 	fmt.Println()
 
 }
+
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,16 @@
+package rag
+
+import (
+	"errors"
+
+	"github.com/openai/openai-go"
+)
+
+// FirstEmbedding returns the first embedding of the response,
+// or an error if the response contains no embedding
+func FirstEmbedding(embeddingsResponse *openai.CreateEmbeddingResponse) ([]float64, error) {
+	if embeddingsResponse == nil || len(embeddingsResponse.Data) == 0 {
+		return nil, errors.New("the embeddings response contains no data")
+	}
+	return embeddingsResponse.Data[0].Embedding, nil
+}
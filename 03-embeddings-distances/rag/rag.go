@@ -1,6 +1,11 @@
 package rag
 
 import (
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"math"
 	"sort"
 	"github.com/google/uuid"
 )
@@ -10,15 +15,41 @@ type VectorRecord struct {
 	Prompt           string    `json:"prompt"`
 	Embedding        []float64 `json:"embedding"`
 	CosineSimilarity float64
+	// Passed is set by SearchTopNSimilaritiesWithMisses when the similarity reaches the limit (not persisted)
+	Passed bool `json:"-"`
 }
 
+// MemoryVectorStore is a vector store keeping the records in memory (see NewMemoryVectorStore)
 type MemoryVectorStore struct {
-	Records map[string]VectorRecord
+	records map[string]VectorRecord
+}
+
+// NewMemoryVectorStore creates an empty memory vector store
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{
+		records: make(map[string]VectorRecord),
+	}
+}
+
+// All yields the records of the store (in no particular order)
+func (mvs *MemoryVectorStore) All() iter.Seq[VectorRecord] {
+	return maps.Values(mvs.records)
+}
+
+// Len returns the number of records of the store
+func (mvs *MemoryVectorStore) Len() int {
+	return len(mvs.records)
+}
+
+// Get returns the record with the given id and whether it is in the store
+func (mvs *MemoryVectorStore) Get(id string) (VectorRecord, bool) {
+	record, ok := mvs.records[id]
+	return record, ok
 }
 
 func (mvs *MemoryVectorStore) GetAll() ([]VectorRecord, error) {
 	var records []VectorRecord
-	for _, record := range mvs.Records {
+	for _, record := range mvs.records {
 		records = append(records, record)
 	}
 	return records, nil
@@ -28,10 +59,28 @@ func (mvs *MemoryVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, err
 	if vectorRecord.Id == "" {
 		vectorRecord.Id = uuid.New().String()
 	}
-	mvs.Records[vectorRecord.Id] = vectorRecord
+	if mvs.records == nil {
+		mvs.records = make(map[string]VectorRecord)
+	}
+	mvs.records[vectorRecord.Id] = vectorRecord
 	return vectorRecord, nil
 }
 
+// ErrInvalidThreshold is returned by the searches when the limit is not a cosine similarity in [-1, 1]
+// (ex: 60 instead of 0.6)
+var ErrInvalidThreshold = errors.New("the similarity threshold must be between -1 and 1")
+
+// checkThreshold rejects the limits out of the cosine similarity range
+func checkThreshold(limit float64) error {
+	if math.IsNaN(limit) || limit < -1.0 || limit > 1.0 {
+		if limit > 1.0 && limit <= 100.0 {
+			return fmt.Errorf("%w: got %v (a percentage? use %v)", ErrInvalidThreshold, limit, limit/100.0)
+		}
+		return fmt.Errorf("%w: got %v", ErrInvalidThreshold, limit)
+	}
+	return nil
+}
+
 // SearchSimilarities searches for vector records in the MemoryVectorStore that have a cosine distance similarity greater than or equal to the given limit.
 //
 // Parameters:
@@ -40,19 +89,27 @@ func (mvs *MemoryVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, err
 //
 // Returns:
 //   - []llm.VectorRecord: a slice of vector records that have a cosine distance similarity greater than or equal to the limit.
-//   - error: an error if any occurred during the search.
+//   - error: an error if any occurred during the search (ErrInvalidThreshold if the limit is not in [-1, 1]).
 func (mvs *MemoryVectorStore) SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error) {
+	if err := checkThreshold(limit); err != nil {
+		return nil, err
+	}
+	return mvs.searchSimilarities(embeddingFromQuestion, limit), nil
+}
+
+// searchSimilarities returns the records with a cosine similarity greater than or equal to the limit (not checked)
+func (mvs *MemoryVectorStore) searchSimilarities(embeddingFromQuestion VectorRecord, limit float64) []VectorRecord {
 
 	var records []VectorRecord
 
-	for _, v := range mvs.Records {
+	for _, v := range mvs.records {
 		distance := CosineSimilarity(embeddingFromQuestion.Embedding, v.Embedding)
 		if distance >= limit {
 			v.CosineSimilarity = distance
 			records = append(records, v)
 		}
 	}
-	return records, nil
+	return records
 }
 
 // SearchTopNSimilarities searches for the top N similar vector records based on the given embedding from a question.
@@ -67,6 +124,20 @@ func (mvs *MemoryVectorStore) SearchTopNSimilarities(embeddingFromQuestion Vecto
 	return getTopNVectorRecords(records, max), nil
 }
 
+// SearchTopNSimilaritiesWithMisses returns the max most similar records whatever the limit,
+// with Passed set for the records whose similarity is greater than or equal to the limit.
+// When no record passes, it shows how close the best match was (ex: 0.58 for a limit of 0.6).
+func (mvs *MemoryVectorStore) SearchTopNSimilaritiesWithMisses(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error) {
+	if err := checkThreshold(limit); err != nil {
+		return nil, err
+	}
+	records := getTopNVectorRecords(mvs.searchSimilarities(embeddingFromQuestion, math.Inf(-1)), max)
+	for i := range records {
+		records[i].Passed = records[i].CosineSimilarity >= limit
+	}
+	return records, nil
+}
+
 // getTopNVectorRecords returns the top N vector records based on their cosine similarity.
 func getTopNVectorRecords(records []VectorRecord, max int) []VectorRecord {
 	// Sort the records slice in descending order based on CosineDistance
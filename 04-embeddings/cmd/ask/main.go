@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"embeddings-demo/llm"
+	"embeddings-demo/rag"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// go run ./cmd/ask -store store.json -chat-model ai/qwen2.5:0.5B-F16
+func main() {
+	config := llm.ConfigFromEnv()
+
+	storeFile := flag.String("store", "store.json", "file of the persisted vector store")
+	flag.StringVar(&config.EmbeddingsModel, "embeddings-model", config.EmbeddingsModel, "embeddings model (the one used to ingest)")
+	flag.StringVar(&config.ChatModel, "chat-model", config.ChatModel, "chat model")
+	topN := flag.Int("top", 3, "maximum number of documents added to the context")
+	threshold := flag.Float64("threshold", 0.6, "minimum cosine similarity of the documents")
+	includeScores := flag.Bool("scores", false, "prefix the documents of the context with their relevance")
+	queriesFile := flag.String("queries", "", "file where the questions and their embeddings are saved on exit (optional)")
+	flag.StringVar(&config.BaseURL, "base-url", config.BaseURL, "Docker Model Runner base URL")
+	flag.Parse()
+	if err := config.Validate(); err != nil {
+		log.Fatalln("😡:", err)
+	}
+	if err := rag.ValidateThreshold(*threshold); err != nil {
+		log.Fatalln("😡:", err)
+	}
+	queryOptions := rag.QueryOptions{
+		IncludeScores: *includeScores,
+		LogQueries:    *queriesFile != "",
+		Embed:         rag.EmbedOptionsForModel(config.EmbeddingsModel),
+	}
+
+	store, err := rag.LoadMemoryVectorStore(*storeFile)
+	if err != nil {
+		log.Fatalln("😡:", err)
+	}
+	fmt.Println("✋", "Store loaded, total of records", store.Len())
+
+	ctx := context.Background()
+	client := llm.NewClient(config.ClientConfig())
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("\n🤔 Question (empty to quit): ")
+		if !scanner.Scan() {
+			break
+		}
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" {
+			break
+		}
+
+		_, sources, err := rag.Query(ctx, client, config.EmbeddingsModel, config.ChatModel, store, question, *topN, *threshold, queryOptions, os.Stdout)
+		if err != nil {
+			fmt.Println("😡:", err)
+			continue
+		}
+
+		fmt.Println()
+		fmt.Println()
+		fmt.Println("📚 Sources:")
+		for _, source := range sources {
+			fmt.Println("✅ CosineSimilarity:", source.Score, "Id:", source.Id)
+		}
+	}
+	if *queriesFile != "" {
+		if err := store.SaveQueriesToFile(*queriesFile); err != nil {
+			log.Fatalln("😡:", err)
+		}
+		fmt.Println("📝 Queries saved to", *queriesFile)
+	}
+	fmt.Println("👋")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"embeddings-demo/llm"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// go run ./cmd/chat -model ai/qwen2.5:0.5B-F16 -prompt "Tell me about the English series called The Avengers?"
+// echo "Who is Emma Peel?" | go run ./cmd/chat -system "You are a useful AI agent expert with TV series."
+func main() {
+	config := llm.ConfigFromEnv()
+
+	flag.StringVar(&config.ChatModel, "model", config.ChatModel, "chat model")
+	flag.StringVar(&config.BaseURL, "base-url", config.BaseURL, "Docker Model Runner base URL")
+	system := flag.String("system", "You are a useful AI agent.", "system instructions")
+	prompt := flag.String("prompt", "", "user prompt (read from stdin if empty)")
+	temperature := flag.Float64("temperature", 0.8, "temperature")
+	preset := flag.String("preset", "", "temperature preset: deterministic, balanced or creative (replaces -temperature)")
+	flag.Parse()
+	if err := config.Validate(); err != nil {
+		log.Fatalln("😡:", err)
+	}
+
+	if *prompt == "" {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalln("😡:", err)
+		}
+		*prompt = strings.TrimSpace(string(input))
+	}
+	if *prompt == "" {
+		log.Fatalln("😡: no prompt, use -prompt or stdin")
+	}
+
+	ctx := context.Background()
+	client := llm.NewClient(config.ClientConfig())
+
+	param := openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(*system),
+			openai.UserMessage(*prompt),
+		},
+		Model: config.ChatModel,
+	}
+	llm.GenOptions{Preset: llm.Preset(*preset), Temperature: *temperature}.Apply(&param)
+
+	if _, err := llm.StreamChat(ctx, client, param, os.Stdout); err != nil {
+		log.Fatalln("😡:", err)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"embeddings-demo/llm"
+	"embeddings-demo/rag"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// go run ./cmd/ingest -dir ./docs -glob "*.md" -strategy markdown -output store.json
+func main() {
+	config := llm.ConfigFromEnv()
+
+	dir := flag.String("dir", ".", "directory to ingest")
+	glob := flag.String("glob", "*.md", "pattern of the file names to ingest")
+	strategy := flag.String("strategy", "markdown", "chunking strategy: markdown, paragraph or fixed")
+	chunkSize := flag.Int("chunk-size", 1024, "chunk size in characters (fixed strategy)")
+	overlap := flag.Int("overlap", 128, "overlap between chunks in characters (fixed strategy)")
+	flag.StringVar(&config.EmbeddingsModel, "model", config.EmbeddingsModel, "embeddings model")
+	output := flag.String("output", "store.json", "file of the persisted vector store")
+	timeout := flag.Duration("timeout", rag.DefaultEmbeddingsTimeout, "maximum duration of an embeddings request (negative: no timeout)")
+	flag.StringVar(&config.BaseURL, "base-url", config.BaseURL, "Docker Model Runner base URL")
+	flag.Parse()
+	if err := config.Validate(); err != nil {
+		log.Fatalln("😡:", err)
+	}
+
+	var split func(text string) []string
+	switch *strategy {
+	case "markdown":
+		split = rag.SplitMarkdownSections
+	case "paragraph":
+		split = rag.SplitParagraphs
+	case "fixed":
+		split = func(text string) []string {
+			return rag.ChunkText(text, *chunkSize, *overlap)
+		}
+	default:
+		log.Fatalln("😡: unknown chunking strategy:", *strategy)
+	}
+
+	ctx := context.Background()
+	client := llm.NewClient(config.ClientConfig())
+
+	store := rag.NewMemoryVectorStore()
+	embedOptions := rag.EmbedOptionsForModel(config.EmbeddingsModel)
+	embedOptions.Timeout = *timeout
+	ingestOptions := rag.IngestOptions{Embed: embedOptions}
+
+	err := filepath.WalkDir(*dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if matched, _ := filepath.Match(*glob, entry.Name()); !matched {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		chunks := split(string(content))
+		fmt.Println("⏳ Ingesting", path, "chunks:", len(chunks))
+
+		metadata := map[string]string{"path": path}
+		if err := rag.IngestChunksWithMetadata(ctx, client, config.EmbeddingsModel, store, chunks, metadata, ingestOptions); err != nil {
+			fmt.Println("😡:", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalln("😡:", err)
+	}
+
+	if err := store.SaveToFile(*output); err != nil {
+		log.Fatalln("😡:", err)
+	}
+	fmt.Println("✋", "Store saved to", *output, "total of records", store.Len())
+}
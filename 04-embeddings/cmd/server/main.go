@@ -0,0 +1,148 @@
+package main
+
+import (
+	"embeddings-demo/llm"
+	"embeddings-demo/rag"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/openai/openai-go"
+)
+
+// go run ./cmd/server -store store.json
+// curl -X POST http://localhost:8080/query -d '{"question": "Who is Emma Peel?"}'
+// curl -N "http://localhost:8080/stream?question=Who+is+Emma+Peel%3F"
+func main() {
+	config := llm.ConfigFromEnv()
+
+	addr := flag.String("addr", ":8080", "listen address")
+	storeFile := flag.String("store", "store.json", "file of the persisted vector store")
+	flag.StringVar(&config.EmbeddingsModel, "embeddings-model", config.EmbeddingsModel, "embeddings model (the one used to ingest)")
+	flag.StringVar(&config.ChatModel, "chat-model", config.ChatModel, "chat model")
+	topN := flag.Int("top", 3, "maximum number of documents added to the context")
+	threshold := flag.Float64("threshold", 0.6, "minimum cosine similarity of the documents")
+	flag.StringVar(&config.BaseURL, "base-url", config.BaseURL, "Docker Model Runner base URL")
+	flag.Parse()
+	if err := config.Validate(); err != nil {
+		log.Fatalln("😡:", err)
+	}
+	if err := rag.ValidateThreshold(*threshold); err != nil {
+		log.Fatalln("😡:", err)
+	}
+
+	store, err := rag.LoadMemoryVectorStore(*storeFile)
+	if err != nil {
+		log.Fatalln("😡:", err)
+	}
+
+	server := &ragServer{
+		client:          llm.NewClient(config.ClientConfig()),
+		store:           store,
+		embeddingsModel: config.EmbeddingsModel,
+		chatModel:       config.ChatModel,
+		topN:            *topN,
+		threshold:       *threshold,
+		queryOptions:    rag.QueryOptions{Embed: rag.EmbedOptionsForModel(config.EmbeddingsModel)},
+	}
+
+	http.HandleFunc("POST /query", server.handleQuery)
+	http.HandleFunc("GET /stream", server.handleStream)
+
+	log.Println("🚀 Listening on", *addr, "records:", store.Len())
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// ragServer answers the questions with the documents of the store
+type ragServer struct {
+	client          openai.Client
+	store           rag.VectorStore
+	embeddingsModel string
+	chatModel       string
+	topN            int
+	threshold       float64
+	queryOptions    rag.QueryOptions
+}
+
+type queryRequest struct {
+	Question string `json:"question"`
+}
+
+type queryResponse struct {
+	Answer  string             `json:"answer"`
+	Sources []rag.SearchResult `json:"sources"`
+}
+
+// handleQuery handles POST /query {"question": "..."} and returns {"answer": "...", "sources": [...]}
+func (s *ragServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var request queryRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Question == "" {
+		http.Error(w, `expected {"question": "..."}`, http.StatusBadRequest)
+		return
+	}
+
+	answer, sources, err := rag.Query(r.Context(), s.client, s.embeddingsModel, s.chatModel, s.store, request.Question, s.topN, s.threshold, s.queryOptions, io.Discard)
+	if err != nil {
+		log.Println("😡:", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(queryResponse{Answer: answer, Sources: sources}); err != nil {
+		log.Println("😡:", err)
+	}
+}
+
+// handleStream handles GET /stream?question=... and streams the answer as Server-Sent Events:
+// a "delta" event for every chunk of the answer (JSON string), then a "sources" event
+// (or an "error" event if the query failed)
+func (s *ragServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	question := r.URL.Query().Get("question")
+	if question == "" {
+		http.Error(w, "the question parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	events := &sseWriter{w: w, controller: http.NewResponseController(w)}
+
+	_, sources, err := rag.Query(r.Context(), s.client, s.embeddingsModel, s.chatModel, s.store, question, s.topN, s.threshold, s.queryOptions, events)
+	if err != nil {
+		log.Println("😡:", err)
+		events.send("error", err.Error())
+		return
+	}
+	events.send("sources", sources)
+}
+
+// sseWriter sends every write as a "delta" Server-Sent Event
+type sseWriter struct {
+	w          io.Writer
+	controller *http.ResponseController
+}
+
+// Write sends p as a "delta" event
+func (sw *sseWriter) Write(p []byte) (int, error) {
+	if err := sw.send("delta", string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// send writes the JSON encoded data as an event and flushes it to the client
+func (sw *sseWriter) send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(sw.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return sw.controller.Flush()
+}
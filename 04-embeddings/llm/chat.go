@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// StreamChat streams the chat completion to w as the chunks arrive and returns the whole content.
+// If the completion was truncated by the max tokens, the error is ErrTruncated.
+func StreamChat(ctx context.Context, client openai.Client, params openai.ChatCompletionNewParams, w io.Writer) (string, error) {
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+
+	var content strings.Builder
+	finishReason := ""
+	for stream.Next() {
+		choice, ok := streamChoice(stream.Current())
+		if !ok {
+			continue
+		}
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+		// Stream each chunk as it arrives
+		if choice.Delta.Content != "" {
+			content.WriteString(choice.Delta.Content)
+			if _, err := io.WriteString(w, choice.Delta.Content); err != nil {
+				return content.String(), err
+			}
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return content.String(), err
+	}
+	return content.String(), checkFinishReason(finishReason)
+}
+
+// streamChoice returns the choice of index 0 of a streamed chunk, or false if the chunk has no choice
+// (ex: a last chunk carrying only the usage). The runners differ on the first and last chunks:
+// the first one may only carry the role and the last one only the finish reason,
+// their Delta.Content is empty and must not be taken for content.
+func streamChoice(chunk openai.ChatCompletionChunk) (openai.ChatCompletionChunkChoice, bool) {
+	for _, choice := range chunk.Choices {
+		if choice.Index == 0 {
+			return choice, true
+		}
+	}
+	return openai.ChatCompletionChunkChoice{}, false
+}
+
+// StreamToChannel works like StreamChat but sends the content deltas on the first channel,
+// to consume the stream in another goroutine (ex: a websocket hub).
+// The terminal error (if any) is sent on the second channel, then both channels are closed:
+//
+//	deltas, errs := llm.StreamToChannel(ctx, client, params)
+//	for delta := range deltas {
+//		fmt.Print(delta)
+//	}
+//	if err := <-errs; err != nil {
+//		...
+//	}
+//
+// The deltas must be read until the channel is closed, or ctx cancelled to stop the stream.
+func StreamToChannel(ctx context.Context, client openai.Client, params openai.ChatCompletionNewParams) (<-chan string, <-chan error) {
+	deltas := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(deltas)
+		if _, err := StreamChat(ctx, client, params, channelWriter{ctx: ctx, deltas: deltas}); err != nil {
+			errs <- err
+		}
+	}()
+	return deltas, errs
+}
+
+// channelWriter is a writer sending every write on a channel until the context is done
+type channelWriter struct {
+	ctx    context.Context
+	deltas chan<- string
+}
+
+// Write sends the text on the channel
+func (cw channelWriter) Write(p []byte) (int, error) {
+	select {
+	case cw.deltas <- string(p):
+		return len(p), nil
+	case <-cw.ctx.Done():
+		return 0, cw.ctx.Err()
+	}
+}
@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// ClientConfig holds the settings used to create an OpenAI client for the Docker Model Runner
+type ClientConfig struct {
+	// BaseURL is the Docker Model Runner base URL (ex: http://localhost:12434)
+	BaseURL string
+	// APIKey is sent as a bearer token. Leave it empty for a local runner.
+	APIKey string
+	// Headers are extra headers added to every request (ex: for an authenticated gateway)
+	Headers map[string]string
+	// HTTPClient is used to send the requests. If nil, a client honoring
+	// HTTP_PROXY/HTTPS_PROXY and TLSConfig is created.
+	HTTPClient *http.Client
+	// TLSConfig is used by the default HTTP client (ex: to trust an internal CA)
+	TLSConfig *tls.Config
+	// Logger enables the logging of the requests and responses (off if nil)
+	Logger *log.Logger
+	// LogBodies also logs the request and response JSON bodies
+	LogBodies bool
+}
+
+// ClientConfigFromEnv returns a client configuration built from
+// MODEL_RUNNER_BASE_URL and MODEL_RUNNER_API_KEY
+func ClientConfigFromEnv() ClientConfig {
+	return ClientConfig{
+		BaseURL: os.Getenv("MODEL_RUNNER_BASE_URL"),
+		APIKey:  os.Getenv("MODEL_RUNNER_API_KEY"),
+	}
+}
+
+// EngineURL returns the OpenAI compatible endpoint of the llama.cpp engine
+func EngineURL(baseURL string) string {
+	return JoinURL(baseURL, "engines/llama.cpp/v1") + "/"
+}
+
+// JoinURL joins the base URL and the path segments with exactly one slash between each part
+// (ex: JoinURL("http://localhost:12434/", "/models") is "http://localhost:12434/models")
+func JoinURL(baseURL string, segments ...string) string {
+	joined := strings.TrimRight(baseURL, "/")
+	for _, segment := range segments {
+		if segment = strings.Trim(segment, "/"); segment != "" {
+			joined += "/" + segment
+		}
+	}
+	return joined
+}
+
+// NewClient creates an OpenAI client targeting the Docker Model Runner.
+// If the configuration has no API key, MODEL_RUNNER_API_KEY is used when present,
+// otherwise the client does not authenticate (local runner).
+func NewClient(config ClientConfig) openai.Client {
+	options := []option.RequestOption{
+		option.WithBaseURL(EngineURL(config.BaseURL)),
+		option.WithAPIKey(config.apiKey()),
+	}
+	for key, value := range config.Headers {
+		options = append(options, option.WithHeader(key, value))
+	}
+	options = append(options, option.WithHTTPClient(config.httpClient()))
+
+	return openai.NewClient(options...)
+}
+
+// apiKey returns the API key of the configuration, or MODEL_RUNNER_API_KEY if it is empty
+func (config ClientConfig) apiKey() string {
+	if config.APIKey != "" {
+		return config.APIKey
+	}
+	return os.Getenv("MODEL_RUNNER_API_KEY")
+}
+
+// httpClient returns the HTTP client of the configuration (or a new one honoring the proxy variables and TLSConfig),
+// wrapped in a LoggingTransport when Logger is set
+func (config ClientConfig) httpClient() *http.Client {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = NewHTTPClient(config.TLSConfig)
+	}
+	if config.Logger != nil {
+		loggingClient := *httpClient
+		loggingClient.Transport = &LoggingTransport{
+			Transport: httpClient.Transport,
+			Logger:    config.Logger,
+			LogBodies: config.LogBodies,
+			APIKey:    config.apiKey(),
+		}
+		httpClient = &loggingClient
+	}
+	return httpClient
+}
+
+// NewHTTPClient creates an HTTP client that honors HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+// and uses the given TLS configuration (nil means the system defaults)
+func NewHTTPClient(tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: transport}
+}
+
+// TLSConfigWithCA returns a TLS configuration trusting the system CAs
+// and the PEM encoded certificates of caCertFile
+func TLSConfigWithCA(caCertFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", caCertFile)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
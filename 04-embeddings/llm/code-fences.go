@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// StripCodeFences removes the markdown code fences (``` or ```json) surrounding an answer,
+// small models often wrap the JSON they are asked for in them.
+// The text is returned unchanged if it doesn't start with a fence.
+func StripCodeFences(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "```") {
+		return s
+	}
+
+	body := strings.TrimPrefix(trimmed, "```")
+	// drop the language tag of the opening fence (ex: json), it ends with a space or a newline
+	if tagEnd := strings.IndexFunc(body, unicode.IsSpace); tagEnd >= 0 && !strings.ContainsAny(body[:tagEnd], "{[\"") {
+		body = body[tagEnd:]
+	}
+	body = strings.TrimSuffix(strings.TrimSpace(body), "```")
+	return strings.TrimSpace(body)
+}
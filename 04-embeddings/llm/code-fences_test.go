@@ -0,0 +1,27 @@
+package llm
+
+import "testing"
+
+func TestStripCodeFences(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"language tag", "```json\n{\"name\": \"France\"}\n```", `{"name": "France"}`},
+		{"language tag and space", "```json {\"name\": \"France\"}```", `{"name": "France"}`},
+		{"no language tag", "```\n[1, 2]\n```", "[1, 2]"},
+		{"on one line", "```{\"name\": \"France\"}```", `{"name": "France"}`},
+		{"JSON on the fence line", "```{\n\"name\": \"France\"}\n```", "{\n\"name\": \"France\"}"},
+		{"surrounding spaces", "\n  ```json\n[1, 2]\n```  \n", "[1, 2]"},
+		{"unterminated fence", "```json\n{\"name\": \"France\"}", `{"name": "France"}`},
+		{"no fence", ` {"name": "France"} `, ` {"name": "France"} `},
+		{"text before the fence", "Here it is:\n```json\n[1]\n```", "Here it is:\n```json\n[1]\n```"},
+		{"empty", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := StripCodeFences(test.input); got != test.want {
+				t.Errorf("StripCodeFences(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
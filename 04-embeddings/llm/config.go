@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Default models used when MODEL_RUNNER_LLM_EMBEDDINGS or MODEL_RUNNER_LLM_CHAT are not set
+const (
+	DefaultEmbeddingsModel = "ai/mxbai-embed-large"
+	DefaultChatModel       = "ai/qwen2.5:0.5B-F16"
+)
+
+// Config is the configuration of the demos, loaded from the environment by LoadConfig
+type Config struct {
+	// BaseURL is the Docker Model Runner base URL, without trailing slash (MODEL_RUNNER_BASE_URL)
+	BaseURL string
+	// ChatModel is MODEL_RUNNER_LLM_CHAT (default: DefaultChatModel)
+	ChatModel string
+	// EmbeddingsModel is MODEL_RUNNER_LLM_EMBEDDINGS (default: DefaultEmbeddingsModel)
+	EmbeddingsModel string
+	// ToolsModel is MODEL_RUNNER_LLM_TOOLS (default: the chat model)
+	ToolsModel string
+}
+
+// LoadConfig loads the configuration from the environment and validates it
+func LoadConfig() (Config, error) {
+	config := ConfigFromEnv()
+	return config, config.Validate()
+}
+
+// ConfigFromEnv loads the configuration from the environment without validating it
+// (ex: to override it with command line flags before calling Validate)
+func ConfigFromEnv() Config {
+	config := Config{
+		BaseURL:         strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/"),
+		ChatModel:       ChatModelFromEnv(),
+		EmbeddingsModel: EmbeddingsModelFromEnv(),
+	}
+	config.ToolsModel = getEnv("MODEL_RUNNER_LLM_TOOLS", config.ChatModel)
+	return config
+}
+
+// Validate checks that the base URL is set and is a valid http(s) URL
+func (config Config) Validate() error {
+	if config.BaseURL == "" {
+		return errors.New("MODEL_RUNNER_BASE_URL is not set (ex: http://localhost:12434)")
+	}
+	baseURL, err := url.Parse(config.BaseURL)
+	if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return fmt.Errorf("MODEL_RUNNER_BASE_URL is not a valid http(s) URL: %q", config.BaseURL)
+	}
+	return nil
+}
+
+// ClientConfig returns the client configuration targeting the configured base URL
+func (config Config) ClientConfig() ClientConfig {
+	clientConfig := ClientConfigFromEnv()
+	clientConfig.BaseURL = config.BaseURL
+	return clientConfig
+}
+
+// EmbeddingsModelFromEnv returns MODEL_RUNNER_LLM_EMBEDDINGS or DefaultEmbeddingsModel
+func EmbeddingsModelFromEnv() string {
+	return getEnv("MODEL_RUNNER_LLM_EMBEDDINGS", DefaultEmbeddingsModel)
+}
+
+// ChatModelFromEnv returns MODEL_RUNNER_LLM_CHAT or DefaultChatModel
+func ChatModelFromEnv() string {
+	return getEnv("MODEL_RUNNER_LLM_CHAT", DefaultChatModel)
+}
+
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
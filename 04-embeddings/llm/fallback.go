@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// CompleteWithFallback tries the chat completion with every model in order,
+// moving to the next model only when the current one is not found on the runner.
+// It returns the completion and the model that answered.
+func CompleteWithFallback(ctx context.Context, client openai.Client, models []string, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, string, error) {
+	var errs []error
+	for _, model := range models {
+		params.Model = model
+		completion, err := client.Chat.Completions.New(ctx, params)
+		if err == nil {
+			return completion, model, nil
+		}
+		if !isModelNotFound(err) {
+			return nil, model, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", model, err))
+	}
+	return nil, "", fmt.Errorf("%w: no model of the chain is available: %w", ErrModelNotFound, errors.Join(errs...))
+}
+
+// isModelNotFound reports if the error of a request means that the model is not available
+func isModelNotFound(err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound ||
+		strings.Contains(strings.ToLower(err.Error()), "model not found")
+}
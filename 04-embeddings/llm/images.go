@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+
+	"github.com/openai/openai-go"
+)
+
+// UserMessageWithImages creates a user message made of the text and the images, for the vision models.
+// An image is an URL or base64 data (see ImageDataURL and ImageFileDataURL).
+func UserMessageWithImages(text string, imageURLs []string) openai.ChatCompletionMessageParamUnion {
+	parts := make([]openai.ChatCompletionContentPartUnionParam, 0, len(imageURLs)+1)
+	if text != "" {
+		parts = append(parts, openai.TextContentPart(text))
+	}
+	for _, imageURL := range imageURLs {
+		parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+			URL: imageURL,
+		}))
+	}
+	return openai.UserMessage(parts)
+}
+
+// ImageDataURL returns the base64 data URL of an image (ex: data:image/png;base64,iVBORw0...).
+// If mimeType is empty, it is detected from the data.
+func ImageDataURL(data []byte, mimeType string) string {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// ImageFileDataURL reads an image file and returns its base64 data URL (see ImageDataURL)
+func ImageFileDataURL(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return ImageDataURL(data, ""), nil
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// LoggingTransport is an http.RoundTripper logging the requests sent to the model runner
+// and their responses. The API key is never written to the logs.
+type LoggingTransport struct {
+	// Transport sends the requests (http.DefaultTransport if nil)
+	Transport http.RoundTripper
+	// Logger receives the logs
+	Logger *log.Logger
+	// LogBodies also logs the request and response JSON bodies
+	LogBodies bool
+	// APIKey is redacted from the logged bodies
+	APIKey string
+}
+
+// RoundTrip logs the request, sends it and logs the response
+func (lt *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := lt.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	lt.Logger.Println("➡️ ", req.Method, req.URL.String())
+	if lt.LogBodies && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		lt.Logger.Println("📤", lt.redact(string(body)))
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		lt.Logger.Println("😡", req.Method, req.URL.String(), err)
+		return nil, err
+	}
+
+	lt.Logger.Println("⬅️ ", resp.Status, req.Method, req.URL.String())
+	if lt.LogBodies && resp.Body != nil && !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		lt.Logger.Println("📥", lt.redact(string(body)))
+	}
+	return resp, nil
+}
+
+// redact hides the API key if it appears in text
+func (lt *LoggingTransport) redact(text string) string {
+	if lt.APIKey == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, lt.APIKey, "[REDACTED]")
+}
@@ -0,0 +1,273 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// MockCompletion is a canned answer of a MockTransport to a chat completion request
+type MockCompletion struct {
+	// Content is the answer of the model
+	Content string
+	// Chunks are the content deltas sent when streaming (default: Content split after the spaces)
+	Chunks []string
+	// ToolCalls are the tool calls of the answer
+	ToolCalls []openai.ChatCompletionMessageToolCall
+	// FinishReason is "stop" by default, "tool_calls" if there are tool calls
+	FinishReason string
+	// StatusCode makes the request fail with this HTTP status (ex: 404 for a missing model)
+	StatusCode int
+}
+
+// MockRequest is a request received by a MockTransport
+type MockRequest struct {
+	// Path is the path of the endpoint (ex: /chat/completions)
+	Path string
+	// Body is the JSON body of the request
+	Body []byte
+}
+
+// MockTransport is an http.RoundTripper playing the Docker Model Runner offline, to test the helpers
+// without a running model: the chat completions (streaming or not) are answered with the canned
+// Completions in order, and the embeddings with EmbeddingFunc.
+type MockTransport struct {
+	// Completions are the answers to the chat completion requests, one per request
+	Completions []MockCompletion
+	// EmbeddingFunc returns the embedding of an input of an embeddings request
+	EmbeddingFunc func(input string) []float64
+
+	mutex    sync.Mutex
+	next     int
+	requests []MockRequest
+}
+
+// ErrMockExhausted is returned when a MockTransport receives more chat completion requests than Completions
+var ErrMockExhausted = errors.New("no more mock completions")
+
+// NewMockClient creates an OpenAI client sending its requests to the MockTransport (without retries)
+func NewMockClient(mock *MockTransport) openai.Client {
+	return openai.NewClient(
+		option.WithBaseURL("http://model-runner.mock/engines/llama.cpp/v1/"),
+		option.WithAPIKey(""),
+		option.WithHTTPClient(&http.Client{Transport: mock}),
+		option.WithMaxRetries(0),
+	)
+}
+
+// Requests returns the requests received so far
+func (mt *MockTransport) Requests() []MockRequest {
+	mt.mutex.Lock()
+	defer mt.mutex.Unlock()
+	return append([]MockRequest(nil), mt.requests...)
+}
+
+// RoundTrip answers the chat completion and embeddings requests
+func (mt *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	mt.mutex.Lock()
+	mt.requests = append(mt.requests, MockRequest{Path: req.URL.Path, Body: body})
+	mt.mutex.Unlock()
+
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/chat/completions"):
+		return mt.chatCompletion(req, body)
+	case strings.HasSuffix(req.URL.Path, "/embeddings"):
+		return mt.embeddings(req, body)
+	default:
+		return mockError(req, http.StatusNotFound, "unknown endpoint "+req.URL.Path), nil
+	}
+}
+
+// chatCompletion answers with the next canned completion, as a JSON completion or as a stream of chunks
+func (mt *MockTransport) chatCompletion(req *http.Request, body []byte) (*http.Response, error) {
+	var request struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return mockError(req, http.StatusBadRequest, err.Error()), nil
+	}
+
+	mt.mutex.Lock()
+	if mt.next >= len(mt.Completions) {
+		mt.mutex.Unlock()
+		return nil, fmt.Errorf("%w (%d requests)", ErrMockExhausted, mt.next+1)
+	}
+	completion := mt.Completions[mt.next]
+	mt.next++
+	mt.mutex.Unlock()
+
+	if completion.StatusCode != 0 {
+		return mockError(req, completion.StatusCode, http.StatusText(completion.StatusCode)), nil
+	}
+
+	finishReason := completion.FinishReason
+	if finishReason == "" {
+		finishReason = "stop"
+		if len(completion.ToolCalls) > 0 {
+			finishReason = "tool_calls"
+		}
+	}
+	toolCalls := make([]map[string]any, len(completion.ToolCalls))
+	for i, toolCall := range completion.ToolCalls {
+		toolCalls[i] = map[string]any{
+			"index": i,
+			"id":    toolCall.ID,
+			"type":  "function",
+			"function": map[string]any{
+				"name":      toolCall.Function.Name,
+				"arguments": toolCall.Function.Arguments,
+			},
+		}
+	}
+
+	if !request.Stream {
+		message := map[string]any{"role": "assistant", "content": completion.Content}
+		if len(toolCalls) > 0 {
+			message["tool_calls"] = toolCalls
+		}
+		return mockJSON(req, map[string]any{
+			"id":      "mock",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   request.Model,
+			"choices": []any{map[string]any{
+				"index":         0,
+				"message":       message,
+				"finish_reason": finishReason,
+			}},
+		})
+	}
+
+	chunks := completion.Chunks
+	if chunks == nil && completion.Content != "" {
+		chunks = strings.SplitAfter(completion.Content, " ")
+	}
+	// like the runners, the first chunk only carries the role
+	deltas := []map[string]any{{"role": "assistant"}}
+	for _, chunk := range chunks {
+		deltas = append(deltas, map[string]any{"content": chunk})
+	}
+	if len(toolCalls) > 0 {
+		deltas = append(deltas, map[string]any{"tool_calls": toolCalls})
+	}
+
+	var stream bytes.Buffer
+	writeChunk := func(delta map[string]any, finishReason any) error {
+		data, err := json.Marshal(map[string]any{
+			"id":      "mock",
+			"object":  "chat.completion.chunk",
+			"created": 0,
+			"model":   request.Model,
+			"choices": []any{map[string]any{
+				"index":         0,
+				"delta":         delta,
+				"finish_reason": finishReason,
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&stream, "data: %s\n\n", data)
+		return nil
+	}
+	for _, delta := range deltas {
+		if err := writeChunk(delta, nil); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeChunk(map[string]any{}, finishReason); err != nil {
+		return nil, err
+	}
+	stream.WriteString("data: [DONE]\n\n")
+
+	return mockResponse(req, http.StatusOK, "text/event-stream", stream.Bytes()), nil
+}
+
+// embeddings answers with the embeddings of the inputs computed by EmbeddingFunc
+func (mt *MockTransport) embeddings(req *http.Request, body []byte) (*http.Response, error) {
+	var request struct {
+		Model string          `json:"model"`
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return mockError(req, http.StatusBadRequest, err.Error()), nil
+	}
+	if mt.EmbeddingFunc == nil {
+		return mockError(req, http.StatusNotFound, "no embeddings model"), nil
+	}
+
+	// the input is a string or an array of strings
+	var inputs []string
+	if err := json.Unmarshal(request.Input, &inputs); err != nil {
+		var input string
+		if err := json.Unmarshal(request.Input, &input); err != nil {
+			return mockError(req, http.StatusBadRequest, "invalid input"), nil
+		}
+		inputs = []string{input}
+	}
+
+	data := make([]any, len(inputs))
+	for i, input := range inputs {
+		data[i] = map[string]any{
+			"object":    "embedding",
+			"index":     i,
+			"embedding": mt.EmbeddingFunc(input),
+		}
+	}
+	return mockJSON(req, map[string]any{
+		"object": "list",
+		"data":   data,
+		"model":  request.Model,
+		"usage":  map[string]any{"prompt_tokens": 0, "total_tokens": 0},
+	})
+}
+
+// mockJSON returns a 200 response with the JSON value as body
+func mockJSON(req *http.Request, value any) (*http.Response, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return mockResponse(req, http.StatusOK, "application/json", data), nil
+}
+
+// mockError returns an error response in the OpenAI format
+func mockError(req *http.Request, statusCode int, message string) *http.Response {
+	data, _ := json.Marshal(map[string]any{
+		"error": map[string]any{"message": message, "type": "mock_error"},
+	})
+	return mockResponse(req, statusCode, "application/json", data)
+}
+
+// mockResponse returns a response with the status, the content type and the body
+func mockResponse(req *http.Request, statusCode int, contentType string, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
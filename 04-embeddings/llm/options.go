@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/openai/openai-go"
+)
+
+// Preset is a named temperature and top-p combination for a kind of task
+type Preset string
+
+const (
+	// Deterministic is for the tool detection, the structured output and the RAG answers (temperature 0)
+	Deterministic Preset = "deterministic"
+	// Balanced is for the general chat: temperature 0.5, top-p 0.9
+	Balanced Preset = "balanced"
+	// Creative is for the creative writing and brainstorming: temperature 0.9, top-p 0.95
+	Creative Preset = "creative"
+)
+
+// presets are the sampling settings of the presets
+var presets = map[Preset]struct {
+	temperature float64
+	topP        float64
+}{
+	Deterministic: {temperature: 0.0},
+	Balanced:      {temperature: 0.5, topP: 0.9},
+	Creative:      {temperature: 0.9, topP: 0.95},
+}
+
+// GenOptions are the generation settings applied by the completion helpers.
+// Set Temperature to 0 and a Seed to get reproducible completions.
+type GenOptions struct {
+	// Preset sets the temperature and the top-p of a kind of task (it replaces Temperature,
+	// a TopP set in the options is kept)
+	Preset      Preset
+	Temperature float64
+	// TopP enables nucleus sampling (0 means the model default)
+	TopP float64
+	// Seed makes the sampling deterministic (nil means random)
+	Seed *int64
+	// MaxTokens caps the length of the completion (0 means no limit)
+	MaxTokens int64
+	// Stop sequences end the completion when the model emits one of them
+	Stop []string
+	// EmptyRetries is the number of times a completion with an empty content is retried
+	EmptyRetries int
+	// RetryTemperatureStep is added to the temperature at every retry (0 keeps the temperature)
+	RetryTemperatureStep float64
+	// ToolChoice is "none", "auto", "required" or the name of the function the model must call
+	// (empty means the model default)
+	ToolChoice string
+	// Logger receives the retries and the fallbacks of the helpers (nil discards them)
+	Logger *slog.Logger
+}
+
+// ErrEmptyContent is returned when the completion content is still empty after the retries
+var ErrEmptyContent = errors.New("the completion content is empty")
+
+// ErrTruncated is returned with the partial content when the completion was cut off
+// by the max tokens (finish reason "length")
+var ErrTruncated = errors.New("the completion was truncated by the max tokens")
+
+// checkFinishReason returns ErrTruncated if the finish reason means the completion was cut off
+func checkFinishReason(finishReason string) error {
+	if finishReason == "length" {
+		return ErrTruncated
+	}
+	return nil
+}
+
+// sampling returns the temperature and the top-p of the options, taking the preset into account
+func (opts GenOptions) sampling() (float64, float64) {
+	if opts.Preset == "" {
+		return opts.Temperature, opts.TopP
+	}
+	preset, ok := presets[opts.Preset]
+	if !ok {
+		loggerOrDiscard(opts.Logger).Warn("unknown preset, using the temperature of the options", "preset", opts.Preset)
+		return opts.Temperature, opts.TopP
+	}
+	if opts.TopP > 0 {
+		return preset.temperature, opts.TopP
+	}
+	return preset.temperature, preset.topP
+}
+
+// Apply sets the generation settings on the chat completion parameters
+func (opts GenOptions) Apply(params *openai.ChatCompletionNewParams) {
+	temperature, topP := opts.sampling()
+	params.Temperature = openai.Opt(temperature)
+	if topP > 0 {
+		params.TopP = openai.Opt(topP)
+	}
+	if opts.Seed != nil {
+		params.Seed = openai.Int(*opts.Seed)
+	}
+	if opts.MaxTokens > 0 {
+		params.MaxTokens = openai.Int(opts.MaxTokens)
+	}
+	if len(opts.Stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{
+			OfChatCompletionNewsStopArray: opts.Stop,
+		}
+	}
+	if opts.ToolChoice != "" {
+		params.ToolChoice = ToolChoice(opts.ToolChoice)
+	}
+}
+
+// ToolChoice returns the tool choice parameter: "none", "auto" and "required" are passed as is,
+// any other value forces the call of the function with this name
+func ToolChoice(choice string) openai.ChatCompletionToolChoiceOptionUnionParam {
+	switch choice {
+	case "none", "auto", "required":
+		return openai.ChatCompletionToolChoiceOptionUnionParam{
+			OfAuto: openai.String(choice),
+		}
+	}
+	return openai.ChatCompletionToolChoiceOptionUnionParam{
+		OfChatCompletionNamedToolChoice: &openai.ChatCompletionNamedToolChoiceParam{
+			Function: openai.ChatCompletionNamedToolChoiceFunctionParam{
+				Name: choice,
+			},
+		},
+	}
+}
+
+// Complete returns the content and the finish reason of the chat completion generated with the options.
+// If the completion was truncated, the partial content is returned with ErrTruncated.
+func Complete(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, opts GenOptions) (string, string, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: messages,
+		Model:    model,
+	}
+	opts.Apply(&params)
+
+	return completeContent(ctx, client, params, opts)
+}
+
+// completeContent returns the content and the finish reason of the chat completion,
+// retrying up to opts.EmptyRetries times (nudging the temperature) when the content is empty
+func completeContent(ctx context.Context, client openai.Client, params openai.ChatCompletionNewParams, opts GenOptions) (string, string, error) {
+	for attempt := 0; ; attempt++ {
+		completion, err := client.Chat.Completions.New(ctx, params)
+		if err != nil {
+			return "", "", err
+		}
+		if len(completion.Choices) > 0 && completion.Choices[0].Message.Content != "" {
+			choice := completion.Choices[0]
+			return choice.Message.Content, choice.FinishReason, checkFinishReason(choice.FinishReason)
+		}
+		if attempt >= opts.EmptyRetries {
+			return "", "", ErrEmptyContent
+		}
+
+		loggerOrDiscard(opts.Logger).Warn("empty completion content, retrying", "attempt", attempt+1)
+		if opts.RetryTemperatureStep > 0 {
+			temperature, _ := opts.sampling()
+			params.Temperature = openai.Opt(temperature + float64(attempt+1)*opts.RetryTemperatureStep)
+		}
+	}
+}
+
+// Stream streams the chat completion generated with the options to w and returns the whole content.
+// If the completion was truncated, the error is ErrTruncated.
+func Stream(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, opts GenOptions, w io.Writer) (string, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: messages,
+		Model:    model,
+	}
+	opts.Apply(&params)
+
+	return StreamChat(ctx, client, params, w)
+}
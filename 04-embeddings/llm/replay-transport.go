@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ErrNoRecording is returned by a ReplayTransport in ReplayOnly mode when a request was never recorded
+var ErrNoRecording = errors.New("no recorded response for the request")
+
+// Recording is a request and its response saved by a ReplayTransport
+type Recording struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Request     json.RawMessage `json:"request,omitempty"`
+	StatusCode  int             `json:"status_code"`
+	ContentType string          `json:"content_type"`
+	// Body is the raw response body (JSON or server-sent events when streaming)
+	Body string `json:"body"`
+}
+
+// ReplayTransport is a VCR-style http.RoundTripper for golden tests: the first time a request is sent,
+// it goes to the real runner and the response is recorded in a file of Dir;
+// afterward the recorded response is replayed without any server.
+// The requests are identified by their method, path and body (the headers and the API key are not recorded).
+type ReplayTransport struct {
+	// Dir is the directory of the recordings
+	Dir string
+	// Transport sends the requests that are not recorded yet (http.DefaultTransport if nil)
+	Transport http.RoundTripper
+	// ReplayOnly fails with ErrNoRecording instead of sending the requests that are not recorded (ex: in the CI)
+	ReplayOnly bool
+}
+
+// NewReplayTransport creates a ReplayTransport recording in dir,
+// use it with option.WithHTTPClient(&http.Client{Transport: transport})
+func NewReplayTransport(dir string) *ReplayTransport {
+	return &ReplayTransport{Dir: dir}
+}
+
+// RoundTrip replays the recorded response of the request, or sends the request and records its response
+func (rt *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	file := filepath.Join(rt.Dir, recordingName(req, body))
+	if data, err := os.ReadFile(file); err == nil {
+		var recording Recording
+		if err := json.Unmarshal(data, &recording); err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %w", file, err)
+		}
+		return mockResponse(req, recording.StatusCode, recording.ContentType, []byte(recording.Body)), nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if rt.ReplayOnly {
+		return nil, fmt.Errorf("%w: %s %s (%s)", ErrNoRecording, req.Method, req.URL.Path, file)
+	}
+
+	transport := rt.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	recording := Recording{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(responseBody),
+	}
+	if json.Valid(body) {
+		recording.Request = body
+	}
+	if err := saveRecording(file, recording); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// recordingName returns the file name of the recording of a request:
+// the last segment of the path and the hash of the method, path and body
+// (ex: completions-3f2a9c0d1b7e4a12.json)
+func recordingName(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hash.Write(body)
+	return path.Base(req.URL.Path) + "-" + hex.EncodeToString(hash.Sum(nil))[:16] + ".json"
+}
+
+// saveRecording writes the recording as indented JSON, creating the directory if needed
+func saveRecording(file string, recording Recording) error {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
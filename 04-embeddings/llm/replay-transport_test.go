@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// go test ./llm -run TestReplayGolden -update records the missing fixtures with the runner of MODEL_RUNNER_BASE_URL
+// (delete testdata/replay to record all of them again)
+var update = flag.Bool("update", false, "record the missing golden fixtures with the runner of MODEL_RUNNER_BASE_URL")
+
+// replayClient returns a client created by NewClient replaying the fixtures of testdata/replay
+func replayClient(t *testing.T) openai.Client {
+	t.Helper()
+	config := ClientConfig{BaseURL: "http://model-runner.replay"}
+	transport := &ReplayTransport{Dir: "testdata/replay", ReplayOnly: true}
+	if *update {
+		config.BaseURL = os.Getenv("MODEL_RUNNER_BASE_URL")
+		transport.ReplayOnly = false
+	}
+	config.HTTPClient = &http.Client{Transport: transport}
+	return NewClient(config)
+}
+
+// goldenQuestion is the chat completion of the fixtures (any change needs new recordings)
+func goldenQuestion() openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Model: DefaultChatModel,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("Answer in one short sentence."),
+			openai.UserMessage("Who is Emma Peel?"),
+		},
+		Temperature: openai.Float(0),
+	}
+}
+
+func TestReplayGolden(t *testing.T) {
+	ctx := context.Background()
+	client := replayClient(t)
+	const answer = "Emma Peel is a fictional spy played by Diana Rigg in the British TV series The Avengers."
+
+	t.Run("chat completion", func(t *testing.T) {
+		completion, err := client.Chat.Completions.New(ctx, goldenQuestion())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(completion.Choices) != 1 || completion.Choices[0].Message.Content != answer {
+			t.Errorf("choices = %v, want the answer %q", completion.Choices, answer)
+		}
+	})
+
+	t.Run("streamed chat completion", func(t *testing.T) {
+		var w strings.Builder
+		content, err := StreamChat(ctx, client, goldenQuestion(), &w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content != answer || w.String() != answer {
+			t.Errorf("content = %q, streamed %q, want %q", content, w.String(), answer)
+		}
+	})
+
+	t.Run("embeddings", func(t *testing.T) {
+		response, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+			Model: DefaultEmbeddingsModel,
+			Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: []string{"Emma Peel", "John Steed"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Data) != 2 || len(response.Data[0].Embedding) != 4 {
+			t.Fatalf("data = %v, want 2 embeddings of dimension 4", response.Data)
+		}
+	})
+
+	t.Run("request not recorded", func(t *testing.T) {
+		if *update {
+			t.Skip("the requests are recorded")
+		}
+		params := goldenQuestion()
+		params.Messages = append(params.Messages, openai.UserMessage("And John Steed?"))
+		if _, err := client.Chat.Completions.New(ctx, params, option.WithMaxRetries(0)); !errors.Is(err, ErrNoRecording) {
+			t.Errorf("error = %v, want ErrNoRecording", err)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/responses"
+)
+
+// ErrResponsesNotSupported is returned when the runner does not implement the Responses API
+var ErrResponsesNotSupported = errors.New("the Responses API is not supported by the model runner, use the chat completions")
+
+// CompleteResponses works like StreamChat but uses the Responses API:
+// it streams the output text to w and returns the whole text.
+// If the runner does not implement the endpoint, the error wraps ErrResponsesNotSupported.
+func CompleteResponses(ctx context.Context, client openai.Client, params responses.ResponseNewParams, w io.Writer) (string, error) {
+	stream := client.Responses.NewStreaming(ctx, params)
+
+	var content strings.Builder
+	for stream.Next() {
+		event := stream.Current()
+		if event.Type == "response.output_text.delta" && event.Delta != "" {
+			content.WriteString(event.Delta)
+			if _, err := io.WriteString(w, event.Delta); err != nil {
+				return content.String(), err
+			}
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		var apiErr *openai.Error
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusNotImplemented) {
+			return content.String(), fmt.Errorf("%w: %v", ErrResponsesNotSupported, err)
+		}
+		return content.String(), err
+	}
+	return content.String(), nil
+}
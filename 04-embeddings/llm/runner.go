@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+var (
+	// ErrRunnerUnreachable is returned when the Docker Model Runner does not answer
+	ErrRunnerUnreachable = errors.New("the model runner is unreachable, start Docker Model Runner first")
+	// ErrEndpointNotFound is returned when the runner answers but not at the expected URL
+	ErrEndpointNotFound = errors.New("the model runner endpoint was not found, check MODEL_RUNNER_BASE_URL")
+	// ErrModelNotFound is returned when a model is not available on the runner
+	ErrModelNotFound = errors.New("model not found")
+)
+
+// PingModelRunner checks that the Docker Model Runner answers on config.BaseURL
+// by requesting the list of the models of the llama.cpp engine.
+// The request is sent like the chat requests (HTTP client, API key and headers of the configuration),
+// so it works behind an authenticated gateway or a proxy.
+// Every model given (optional) must be in the list, otherwise the error wraps ErrModelNotFound.
+func PingModelRunner(ctx context.Context, config ClientConfig, models ...string) error {
+	resp, err := sendRunnerRequest(ctx, config, http.MethodGet, JoinURL(EngineURL(config.BaseURL), "models"), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrEndpointNotFound, resp.Request.URL)
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("the model runner answered %s", resp.Status)
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return fmt.Errorf("invalid list of models: %w", err)
+	}
+	available := make([]string, 0, len(list.Data))
+	for _, model := range list.Data {
+		available = append(available, model.ID)
+	}
+	for _, model := range models {
+		if !slices.Contains(available, model) {
+			return modelNotFoundError(model, available)
+		}
+	}
+	return nil
+}
+
+// sendRunnerRequest sends a request to the runner with the HTTP client, the API key and the headers of the configuration.
+// The error wraps ErrRunnerUnreachable if the runner does not answer.
+func sendRunnerRequest(ctx context.Context, config ClientConfig, method, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey := config.apiKey(); apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	for key, value := range config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := config.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRunnerUnreachable, err)
+	}
+	return resp, nil
+}
+
+// ListModels returns the ids of the models available on the runner
+func ListModels(ctx context.Context, client openai.Client) ([]string, error) {
+	page, err := client.Models.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(page.Data))
+	for _, model := range page.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
+// CheckModel returns ErrModelNotFound if the model is not available on the runner
+func CheckModel(ctx context.Context, client openai.Client, model string) error {
+	models, err := ListModels(ctx, client)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(models, model) {
+		return modelNotFoundError(model, models)
+	}
+	return nil
+}
+
+// modelNotFoundError returns the ErrModelNotFound error of the model with the available models
+func modelNotFoundError(model string, available []string) error {
+	return fmt.Errorf("%w: %s (available: %s), run docker model pull %s",
+		ErrModelNotFound, model, strings.Join(available, ", "), model)
+}
+
+// EnsureModel asks the runner to pull the model if it is not available yet
+// and waits until the model is ready or the timeout elapses.
+// The requests are sent like the chat requests (HTTP client, API key and headers of the configuration).
+func EnsureModel(ctx context.Context, config ClientConfig, model string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ready, err := isModelAvailable(ctx, config, model)
+	if err != nil || ready {
+		return err
+	}
+
+	if config.Logger != nil {
+		config.Logger.Printf("pulling the model %s", model)
+	}
+	body, err := json.Marshal(map[string]string{"from": model})
+	if err != nil {
+		return err
+	}
+
+	resp, err := sendRunnerRequest(ctx, config, http.MethodPost, JoinURL(config.BaseURL, "models/create"), body)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", model, err)
+	}
+	// the pull progress is streamed, wait until the end of the download
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("failed to pull %s: %s", model, resp.Status)
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		ready, err := isModelAvailable(ctx, config, model)
+		if err != nil || ready {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s is still not available after %s", ErrModelNotFound, model, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// isModelAvailable checks if the model has been pulled on the runner
+func isModelAvailable(ctx context.Context, config ClientConfig, model string) (bool, error) {
+	resp, err := sendRunnerRequest(ctx, config, http.MethodGet, JoinURL(config.BaseURL, "models", model), nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("the model runner answered %s", resp.Status)
+	}
+}
@@ -0,0 +1,245 @@
+package llm
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// SchemaFromStruct generates the JSON schema of a struct value (or pointer to struct).
+// The property names come from the json tags, the fields are required unless tagged omitempty.
+// Nested structs and slices of structs become nested objects and arrays of objects,
+// the fields of the embedded structs are flattened into the parent like encoding/json does.
+// time.Time and the types implementing json.Marshaler or encoding.TextMarshaler are strings.
+// The allowed values of a field are set with a jsonschema tag: `jsonschema:"enum=Europe|Asia|Africa"`.
+func SchemaFromStruct(v any) map[string]any {
+	return schemaFromType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+// schemaFromType returns the JSON schema of a Go type,
+// visiting are the struct types being generated (a recursive type becomes an object without properties)
+func schemaFromType(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if isMarshaler(t) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{
+			"type":  "array",
+			"items": schemaFromType(t.Elem(), visiting),
+		}
+	case reflect.Map:
+		return map[string]any{"type": "object"}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]any{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		return schemaFromStructType(t, visiting)
+	default:
+		return map[string]any{}
+	}
+}
+
+// isMarshaler reports if the values of the type (or their pointers) marshal themselves,
+// as a string for the text marshalers and usually for the JSON marshalers (ex: decimal types)
+func isMarshaler(t reflect.Type) bool {
+	for _, marshaler := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaField is a property of a struct schema, from a field of the struct or of an embedded struct
+type schemaField struct {
+	name     string
+	schema   map[string]any
+	required bool
+	// depth is the embedding depth of the field (0 for the fields of the struct)
+	depth int
+	// tagged is true when the name comes from the json tag
+	tagged bool
+}
+
+// schemaFromStructType returns the JSON schema of a struct type
+func schemaFromStructType(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for _, field := range dominantFields(structFields(t, 0, true, visiting)) {
+		properties[field.name] = field.schema
+		if field.required {
+			required = append(required, field.name)
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// structFields returns the properties of the fields of the struct type in declaration order,
+// with the fields of the untagged embedded structs at depth+1 (they are optional if the embedded struct is a pointer)
+func structFields(t reflect.Type, depth int, required bool, visiting map[reflect.Type]bool) []schemaField {
+	var fields []schemaField
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag, hasTag := field.Tag.Lookup("json")
+		if tag == "-" {
+			continue
+		}
+		tagName, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && tagName == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			// like encoding/json: the fields of an embedded struct are promoted,
+			// even if its type is unexported (but not through a pointer)
+			if embedded.Kind() == reflect.Struct && !isMarshaler(embedded) {
+				if !field.IsExported() && field.Type.Kind() == reflect.Pointer {
+					continue
+				}
+				if visiting[embedded] {
+					continue
+				}
+				visiting[embedded] = true
+				fields = append(fields, structFields(embedded, depth+1, required && field.Type.Kind() != reflect.Pointer, visiting)...)
+				delete(visiting, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tagName != "" {
+			name = tagName
+		}
+		propertySchema := schemaFromType(field.Type, visiting)
+		if enum := enumFromTag(field.Tag.Get("jsonschema")); enum != nil {
+			propertySchema["enum"] = typedEnum(enum, field.Type)
+		}
+		fields = append(fields, schemaField{
+			name:     name,
+			schema:   propertySchema,
+			required: required && !(hasTag && strings.Contains(options, "omitempty")),
+			depth:    depth,
+			tagged:   tagName != "",
+		})
+	}
+	return fields
+}
+
+// dominantFields resolves the fields with the same name like encoding/json:
+// the least embedded field wins, then the tagged one; the other conflicts drop the property
+func dominantFields(fields []schemaField) []schemaField {
+	byName := map[string][]schemaField{}
+	var names []string
+	for _, field := range fields {
+		if _, ok := byName[field.name]; !ok {
+			names = append(names, field.name)
+		}
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	var dominant []schemaField
+	for _, name := range names {
+		candidates := byName[name]
+		minDepth := candidates[0].depth
+		for _, field := range candidates {
+			minDepth = min(minDepth, field.depth)
+		}
+		var shallowest, tagged []schemaField
+		for _, field := range candidates {
+			if field.depth == minDepth {
+				shallowest = append(shallowest, field)
+				if field.tagged {
+					tagged = append(tagged, field)
+				}
+			}
+		}
+		switch {
+		case len(shallowest) == 1:
+			dominant = append(dominant, shallowest[0])
+		case len(tagged) == 1:
+			dominant = append(dominant, tagged[0])
+		}
+	}
+	return dominant
+}
+
+// enumFromTag returns the values of the enum option of a jsonschema tag (ex: "enum=Europe|Asia")
+func enumFromTag(tag string) []string {
+	for option := range strings.SplitSeq(tag, ",") {
+		if values, ok := strings.CutPrefix(strings.TrimSpace(option), "enum="); ok {
+			return strings.Split(values, "|")
+		}
+	}
+	return nil
+}
+
+// typedEnum converts the values of an enum tag to the type of the field (ex: the numbers of an int field),
+// the values that can't be converted are kept as strings
+func typedEnum(values []string, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var parse func(string) (any, error)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parse = func(value string) (any, error) { return strconv.ParseInt(value, 10, 64) }
+	case reflect.Float32, reflect.Float64:
+		parse = func(value string) (any, error) { return strconv.ParseFloat(value, 64) }
+	case reflect.Bool:
+		parse = func(value string) (any, error) { return strconv.ParseBool(value) }
+	default:
+		return values
+	}
+
+	typed := make([]any, len(values))
+	for i, value := range values {
+		parsed, err := parse(value)
+		if err != nil {
+			typed[i] = value
+			continue
+		}
+		typed[i] = parsed
+	}
+	return typed
+}
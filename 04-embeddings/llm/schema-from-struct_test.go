@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testCity struct {
+	Name       string `json:"name"`
+	Population int    `json:"population,omitempty"`
+}
+
+type testCountry struct {
+	Name      string     `json:"name"`
+	Continent string     `json:"continent" jsonschema:"enum=Europe|Asia"`
+	Capital   testCity   `json:"capital"`
+	Cities    []testCity `json:"cities,omitempty"`
+	internal  string
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	city := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":       map[string]any{"type": "string"},
+			"population": map[string]any{"type": "integer"},
+		},
+		"required": []string{"name"},
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":      map[string]any{"type": "string"},
+			"continent": map[string]any{"type": "string", "enum": []string{"Europe", "Asia"}},
+			"capital":   city,
+			"cities":    map[string]any{"type": "array", "items": city},
+		},
+		"required": []string{"name", "continent", "capital"},
+	}
+
+	got := SchemaFromStruct(&testCountry{})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaFromStruct() = %v, want %v", got, want)
+	}
+
+	// the nested schemas are checked on their own for a readable failure
+	properties := got["properties"].(map[string]any)
+	for _, nested := range []map[string]any{
+		properties["capital"].(map[string]any),
+		properties["cities"].(map[string]any)["items"].(map[string]any),
+	} {
+		if !reflect.DeepEqual(nested["properties"], city["properties"]) {
+			t.Errorf("nested properties = %v, want %v", nested["properties"], city["properties"])
+		}
+		if !reflect.DeepEqual(nested["required"], city["required"]) {
+			t.Errorf("nested required = %v, want %v", nested["required"], city["required"])
+		}
+	}
+}
+
+type testGeography struct {
+	Area      float64 `json:"area"`
+	Continent string  `json:"continent"`
+}
+
+// Population is exported: encoding/json ignores the embedded pointers to unexported structs
+type Population struct {
+	Count int `json:"count"`
+}
+
+type testEmbeddingCountry struct {
+	Name string `json:"name"`
+	testGeography
+	*Population
+	// the field of the struct wins over the field of the embedded struct
+	Continent string    `json:"continent" jsonschema:"enum=Europe|Asia"`
+	Founded   time.Time `json:"founded"`
+	Motto     testMotto `json:"motto"`
+}
+
+// testMotto marshals itself as a string
+type testMotto struct{ text string }
+
+func (m testMotto) MarshalText() ([]byte, error) { return []byte(m.text), nil }
+
+type testNode struct {
+	Name     string     `json:"name"`
+	Children []testNode `json:"children,omitempty"`
+}
+
+func TestSchemaFromStructSpecialFields(t *testing.T) {
+	t.Run("embedded struct", func(t *testing.T) {
+		schema := SchemaFromStruct(testEmbeddingCountry{})
+		properties := schema["properties"].(map[string]any)
+		for _, name := range []string{"name", "area", "continent", "count", "founded", "motto"} {
+			if _, ok := properties[name]; !ok {
+				t.Errorf("missing property %q in %v", name, properties)
+			}
+		}
+		if _, ok := properties["testGeography"]; ok {
+			t.Errorf("the embedded struct is a property: %v", properties)
+		}
+		if continent := properties["continent"].(map[string]any); continent["enum"] == nil {
+			t.Errorf("continent = %v, want the field of the struct", continent)
+		}
+		// the fields of the embedded pointer are optional, like its nil value
+		want := []string{"name", "area", "continent", "founded", "motto"}
+		if !reflect.DeepEqual(schema["required"], want) {
+			t.Errorf("required = %v, want %v", schema["required"], want)
+		}
+
+		// the marshaled struct validates against its schema
+		country := testEmbeddingCountry{
+			Name:          "France",
+			testGeography: testGeography{Area: 551695},
+			Continent:     "Europe",
+			Founded:       time.Date(843, 8, 10, 0, 0, 0, 0, time.UTC),
+			Motto:         testMotto{"Liberté, égalité, fraternité"},
+		}
+		data, err := json.Marshal(country)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateAgainstSchema(data, schema); err != nil {
+			t.Errorf("ValidateAgainstSchema(%s) = %v", data, err)
+		}
+	})
+
+	t.Run("time and marshalers", func(t *testing.T) {
+		properties := SchemaFromStruct(testEmbeddingCountry{})["properties"].(map[string]any)
+		for _, name := range []string{"founded", "motto"} {
+			if schemaType := properties[name].(map[string]any)["type"]; schemaType != "string" {
+				t.Errorf("%s type = %v, want string", name, schemaType)
+			}
+		}
+	})
+
+	t.Run("recursive type", func(t *testing.T) {
+		schema := SchemaFromStruct(testNode{})
+		children := schema["properties"].(map[string]any)["children"].(map[string]any)
+		if items := children["items"]; !reflect.DeepEqual(items, map[string]any{"type": "object"}) {
+			t.Errorf("children items = %v, want an object without properties", items)
+		}
+	})
+}
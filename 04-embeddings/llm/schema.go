@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrSchemaValidation is returned when a JSON answer doesn't match its JSON schema
+var ErrSchemaValidation = errors.New("the JSON doesn't match the schema")
+
+// ValidateAgainstSchema checks that the JSON data matches the JSON schema:
+// the required properties must be present, the values must have the declared types
+// (object, array, string, number, integer, boolean, null) and belong to the enum if any.
+// The errors wrap ErrSchemaValidation.
+func ValidateAgainstSchema(data []byte, schema map[string]any) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: invalid JSON: %v", ErrSchemaValidation, err)
+	}
+	if err := validateValue(value, schema, "$"); err != nil {
+		return fmt.Errorf("%w: %w", ErrSchemaValidation, err)
+	}
+	return nil
+}
+
+// validateValue validates a decoded JSON value against a schema, path locates the value in the document
+func validateValue(value any, schema map[string]any, path string) error {
+	if schemaType, ok := schema["type"].(string); ok {
+		if !hasType(value, schemaType) {
+			return fmt.Errorf("%s: expected %s, got %s", path, schemaType, typeName(value))
+		}
+	}
+	if enum, ok := schema["enum"]; ok && !inEnum(value, enum) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range stringList(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, propertyValue := range v {
+			propertySchema, ok := properties[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			if err := validateValue(propertyValue, propertySchema, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if itemsSchema, ok := schema["items"].(map[string]any); ok {
+			for idx, item := range v {
+				if err := validateValue(item, itemsSchema, fmt.Sprintf("%s[%d]", path, idx)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasType reports if a decoded JSON value has the JSON schema type
+func hasType(value any, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		// unknown types are not checked
+		return true
+	}
+}
+
+// inEnum reports if a decoded JSON value is one of the values of the enum (any slice, ex: []string, []int or []any).
+// The enum goes through JSON like the value, so the numbers of both are float64.
+func inEnum(value any, enum any) bool {
+	data, err := json.Marshal(enum)
+	if err != nil {
+		return true
+	}
+	var values []any
+	if err := json.Unmarshal(data, &values); err != nil {
+		// unknown enum formats are not checked
+		return true
+	}
+	for _, allowed := range values {
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the JSON schema type name of a decoded JSON value
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// stringList converts the "required" list of a schema ([]string or decoded []any)
+func stringList(list any) []string {
+	switch l := list.(type) {
+	case []string:
+		return l
+	case []any:
+		names := make([]string, 0, len(l))
+		for _, item := range l {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidateAgainstSchemaEnum(t *testing.T) {
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(`{"type":"integer","enum":[1,2]}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		data    string
+		schema  map[string]any
+		wantErr bool
+	}{
+		{"string enum", `"Asia"`, map[string]any{"type": "string", "enum": []string{"Europe", "Asia"}}, false},
+		{"string not in enum", `"Mars"`, map[string]any{"type": "string", "enum": []string{"Europe", "Asia"}}, true},
+		{"numeric enum built in Go", `2`, map[string]any{"type": "integer", "enum": []any{1, 2}}, false},
+		{"int slice enum", `2`, map[string]any{"type": "integer", "enum": []int{1, 2}}, false},
+		{"decoded numeric enum", `1`, decoded, false},
+		{"number not in enum", `3`, map[string]any{"type": "integer", "enum": []any{1, 2}}, true},
+		{"number as a string", `"1"`, map[string]any{"enum": []any{1, 2}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateAgainstSchema([]byte(test.data), test.schema)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ValidateAgainstSchema(%s) = %v, want error %v", test.data, err, test.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrSchemaValidation) {
+				t.Errorf("error = %v, want ErrSchemaValidation", err)
+			}
+		})
+	}
+}
+
+func TestSchemaFromStructNumericEnum(t *testing.T) {
+	type rating struct {
+		Stars int `json:"stars" jsonschema:"enum=1|2|3"`
+	}
+	schema := SchemaFromStruct(rating{})
+
+	for stars, wantErr := range map[int]bool{2: false, 4: true} {
+		data, err := json.Marshal(rating{Stars: stars})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateAgainstSchema(data, schema); (err != nil) != wantErr {
+			t.Errorf("ValidateAgainstSchema(%s) = %v, want error %v", data, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// StreamToolCalls streams the content of the chat completion to w as the chunks arrive
+// and assembles the tool calls from the streamed deltas: the fragments of a tool call
+// (id, name, pieces of the arguments) arrive in several chunks with the same index.
+// It returns the content and the tool calls ordered by index.
+// If the completion was truncated by the max tokens, the error is ErrTruncated.
+func StreamToolCalls(ctx context.Context, client openai.Client, params openai.ChatCompletionNewParams, w io.Writer) (string, []openai.ChatCompletionMessageToolCall, error) {
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+
+	var content strings.Builder
+	toolCalls := map[int64]*openai.ChatCompletionMessageToolCall{}
+	finishReason := ""
+	for stream.Next() {
+		choice, ok := streamChoice(stream.Current())
+		if !ok {
+			continue
+		}
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+
+		if choice.Delta.Content != "" {
+			content.WriteString(choice.Delta.Content)
+			if _, err := io.WriteString(w, choice.Delta.Content); err != nil {
+				return content.String(), nil, err
+			}
+		}
+
+		for _, delta := range choice.Delta.ToolCalls {
+			toolCall, ok := toolCalls[delta.Index]
+			if !ok {
+				toolCall = &openai.ChatCompletionMessageToolCall{}
+				toolCalls[delta.Index] = toolCall
+			}
+			// The id and the name come once, the arguments come in pieces
+			if delta.ID != "" {
+				toolCall.ID = delta.ID
+			}
+			if delta.Function.Name != "" {
+				toolCall.Function.Name = delta.Function.Name
+			}
+			toolCall.Function.Arguments += delta.Function.Arguments
+		}
+	}
+
+	assembled := make([]openai.ChatCompletionMessageToolCall, 0, len(toolCalls))
+	for _, index := range slices.Sorted(maps.Keys(toolCalls)) {
+		assembled = append(assembled, *toolCalls[index])
+	}
+
+	if err := stream.Err(); err != nil {
+		return content.String(), assembled, err
+	}
+	return content.String(), assembled, checkFinishReason(finishReason)
+}
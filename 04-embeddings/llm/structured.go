@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// GenerateStructured asks the model for a JSON answer following the schema
+// and returns it once validated against the schema.
+// The markdown code fences around the JSON are removed (see StripCodeFences).
+// If the answer was truncated by the max tokens, the partial JSON is returned with ErrTruncated.
+func GenerateStructured(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, name string, schema map[string]any, opts GenOptions) ([]byte, error) {
+	params := structuredParams(model, messages, name, schema)
+	opts.Apply(&params)
+
+	content, _, err := completeContent(ctx, client, params, opts)
+	if errors.Is(err, ErrTruncated) {
+		return []byte(StripCodeFences(content)), err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte(StripCodeFences(content))
+	if err := ValidateAgainstSchema(data, schema); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// GenerateJSONObject works like GenerateStructured for the models that don't honor the JSON schema
+// constraint: the answer is only constrained to be a JSON object (response_format json_object),
+// the schema is given to the model in a system message and the answer is validated against it.
+func GenerateJSONObject(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, schema map[string]any, opts GenOptions) ([]byte, error) {
+	params, err := jsonObjectParams(model, messages, schema)
+	if err != nil {
+		return nil, err
+	}
+	opts.Apply(&params)
+
+	content, _, err := completeContent(ctx, client, params, opts)
+	if errors.Is(err, ErrTruncated) {
+		return []byte(StripCodeFences(content)), err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte(StripCodeFences(content))
+	if err := ValidateAgainstSchema(data, schema); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// GenerateStructuredWithFallback calls GenerateStructured and falls back to GenerateJSONObject
+// when the model rejects the JSON schema (4xx error of the request) or ignores it (ErrSchemaValidation).
+// The other errors (unreachable runner, server error, truncated answer, cancelled context) are returned as is.
+func GenerateStructuredWithFallback(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, name string, schema map[string]any, opts GenOptions) ([]byte, error) {
+	data, err := GenerateStructured(ctx, client, model, messages, name, schema, opts)
+	if err == nil || ctx.Err() != nil || !isSchemaRejected(err) {
+		return data, err
+	}
+	loggerOrDiscard(opts.Logger).Warn("the JSON schema failed, falling back to the JSON object mode", "model", model, "error", err)
+	return GenerateJSONObject(ctx, client, model, messages, schema, opts)
+}
+
+// isSchemaRejected reports if the error means that the model doesn't support the JSON schema:
+// the request was rejected (4xx) or the answer doesn't validate
+func isSchemaRejected(err error) bool {
+	if errors.Is(err, ErrSchemaValidation) {
+		return true
+	}
+	var apiErr *openai.Error
+	return errors.As(err, &apiErr) &&
+		apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError
+}
+
+// StreamStructured works like GenerateStructured but streams the completion:
+// onPartial is called with the JSON accumulated so far every time a chunk arrives,
+// so the caller can try to parse it progressively.
+// The complete JSON is validated against the schema at the end of the stream.
+func StreamStructured(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, name string, schema map[string]any, opts GenOptions, onPartial func(buffer string)) ([]byte, error) {
+	params := structuredParams(model, messages, name, schema)
+	opts.Apply(&params)
+
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+
+	var buffer strings.Builder
+	finishReason := ""
+	for stream.Next() {
+		choice, ok := streamChoice(stream.Current())
+		if !ok {
+			continue
+		}
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+		if choice.Delta.Content != "" {
+			buffer.WriteString(choice.Delta.Content)
+			if onPartial != nil {
+				onPartial(buffer.String())
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return []byte(buffer.String()), err
+	}
+	if err := checkFinishReason(finishReason); err != nil {
+		return []byte(StripCodeFences(buffer.String())), err
+	}
+
+	data := []byte(StripCodeFences(buffer.String()))
+	if err := ValidateAgainstSchema(data, schema); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// structuredParams returns the chat completion parameters constraining the answer to the JSON schema
+func structuredParams(model string, messages []openai.ChatCompletionMessageParamUnion, name string, schema map[string]any) openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Messages: messages,
+		Model:    model,
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+				JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   name,
+					Schema: schema,
+					Strict: openai.Bool(true),
+				},
+			},
+		},
+	}
+}
+
+// jsonObjectParams returns the chat completion parameters constraining the answer to a JSON object,
+// with a first system message describing the expected schema
+func jsonObjectParams(model string, messages []openai.ChatCompletionMessageParamUnion, schema map[string]any) (openai.ChatCompletionNewParams, error) {
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return openai.ChatCompletionNewParams{}, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	instructions := "Answer only with a JSON object following this JSON schema:\n" + string(schemaJSON)
+
+	return openai.ChatCompletionNewParams{
+		Messages: append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(instructions)}, messages...),
+		Model:    model,
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &openai.ResponseFormatJSONObjectParam{},
+		},
+	}, nil
+}
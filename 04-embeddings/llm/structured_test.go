@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+func TestGenerateStructuredWithFallback(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"name": map[string]any{"type": "string"}},
+		"required":   []string{"name"},
+	}
+	tests := []struct {
+		name         string
+		completions  []MockCompletion
+		wantRequests int
+		wantErr      bool
+	}{
+		{"schema honored", []MockCompletion{{Content: `{"name":"Bob"}`}}, 1, false},
+		{"schema rejected", []MockCompletion{{StatusCode: http.StatusBadRequest}, {Content: `{"name":"Bob"}`}}, 2, false},
+		{"schema ignored", []MockCompletion{{Content: `{"age":42}`}, {Content: `{"name":"Bob"}`}}, 2, false},
+		{"server error", []MockCompletion{{StatusCode: http.StatusInternalServerError}}, 1, true},
+		{"truncated", []MockCompletion{{Content: `{"na`, FinishReason: "length"}}, 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := &MockTransport{Completions: test.completions}
+			messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Who?")}
+
+			data, err := GenerateStructuredWithFallback(context.Background(), NewMockClient(mock), "mock-model", messages, "person", schema, GenOptions{})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && string(data) != `{"name":"Bob"}` {
+				t.Errorf("data = %s", data)
+			}
+			if got := len(mock.Requests()); got != test.wantRequests {
+				t.Errorf("%d requests, want %d", got, test.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// ThinkSplitter is a writer separating the reasoning of the model (inside <think>...</think>, Qwen models)
+// from the answer in a streamed content: the reasoning goes to one writer and the answer to the other.
+// The tags can be split across several writes.
+type ThinkSplitter struct {
+	content   io.Writer
+	reasoning io.Writer
+
+	contentText   strings.Builder
+	reasoningText strings.Builder
+	inThink       bool
+	// pending is the end of the last write that may be the beginning of a tag
+	pending string
+}
+
+// NewThinkSplitter creates a ThinkSplitter writing the answer to content and the reasoning to reasoning
+// (a nil writer discards the text)
+func NewThinkSplitter(content, reasoning io.Writer) *ThinkSplitter {
+	if content == nil {
+		content = io.Discard
+	}
+	if reasoning == nil {
+		reasoning = io.Discard
+	}
+	return &ThinkSplitter{content: content, reasoning: reasoning}
+}
+
+// Write splits the text between the answer and the reasoning
+func (s *ThinkSplitter) Write(p []byte) (int, error) {
+	text := s.pending + string(p)
+	s.pending = ""
+
+	for {
+		tag := thinkOpenTag
+		if s.inThink {
+			tag = thinkCloseTag
+		}
+		if index := strings.Index(text, tag); index >= 0 {
+			if err := s.emit(text[:index]); err != nil {
+				return len(p), err
+			}
+			text = text[index+len(tag):]
+			s.inThink = !s.inThink
+			continue
+		}
+		// keep the end of the text if it may be the beginning of the tag
+		keep := partialTagLength(text, tag)
+		s.pending = text[len(text)-keep:]
+		return len(p), s.emit(text[:len(text)-keep])
+	}
+}
+
+// Flush writes the text kept while waiting for the end of a tag
+func (s *ThinkSplitter) Flush() error {
+	text := s.pending
+	s.pending = ""
+	return s.emit(text)
+}
+
+// Content returns the answer without the reasoning
+func (s *ThinkSplitter) Content() string {
+	return strings.TrimSpace(s.contentText.String())
+}
+
+// Reasoning returns the text of the <think> blocks
+func (s *ThinkSplitter) Reasoning() string {
+	return strings.TrimSpace(s.reasoningText.String())
+}
+
+// emit writes the text to the reasoning or to the answer depending on the current block
+func (s *ThinkSplitter) emit(text string) error {
+	if text == "" {
+		return nil
+	}
+	if s.inThink {
+		s.reasoningText.WriteString(text)
+		_, err := io.WriteString(s.reasoning, text)
+		return err
+	}
+	s.contentText.WriteString(text)
+	_, err := io.WriteString(s.content, text)
+	return err
+}
+
+// partialTagLength returns the length of the longest end of the text that is the beginning of the tag
+func partialTagLength(text, tag string) int {
+	for length := min(len(text), len(tag)-1); length > 0; length-- {
+		if strings.HasSuffix(text, tag[:length]) {
+			return length
+		}
+	}
+	return 0
+}
+
+// StreamChatWithReasoning works like StreamChat but separates the reasoning (<think> blocks) from the answer:
+// the answer is streamed to w, the reasoning to reasoning (nil to discard it), and both are returned.
+func StreamChatWithReasoning(ctx context.Context, client openai.Client, params openai.ChatCompletionNewParams, w io.Writer, reasoning io.Writer) (string, string, error) {
+	splitter := NewThinkSplitter(w, reasoning)
+	_, err := StreamChat(ctx, client, params, splitter)
+	if flushErr := splitter.Flush(); err == nil {
+		err = flushErr
+	}
+	return splitter.Content(), splitter.Reasoning(), err
+}
@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestThinkSplitter(t *testing.T) {
+	tests := []struct {
+		name          string
+		chunks        []string
+		wantContent   string
+		wantReasoning string
+	}{
+		{"no reasoning", []string{"Hello ", "world"}, "Hello world", ""},
+		{"tags in one chunk", []string{"<think>hmm</think>Hello"}, "Hello", "hmm"},
+		{"open tag split", []string{"<th", "ink>hmm</think>Hello"}, "Hello", "hmm"},
+		{"close tag split", []string{"<think>hmm</", "thi", "nk>Hello"}, "Hello", "hmm"},
+		{"tag split in single characters", strings.Split("<think>hmm</think>Hello", ""), "Hello", "hmm"},
+		{"not a tag", []string{"1 <", " 2 <th"}, "1 < 2 <th", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var content, reasoning strings.Builder
+			splitter := NewThinkSplitter(&content, &reasoning)
+			for _, chunk := range test.chunks {
+				if _, err := io.WriteString(splitter, chunk); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := splitter.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if content.String() != test.wantContent || splitter.Content() != test.wantContent {
+				t.Errorf("content = %q, want %q", content.String(), test.wantContent)
+			}
+			if reasoning.String() != test.wantReasoning || splitter.Reasoning() != test.wantReasoning {
+				t.Errorf("reasoning = %q, want %q", reasoning.String(), test.wantReasoning)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// decodeToolArguments decodes the JSON arguments of a tool call.
+// If they are not valid JSON, it tries again with the arguments fixed by RepairToolArguments.
+func decodeToolArguments(arguments string, logger *slog.Logger) (map[string]any, error) {
+	var decoded map[string]any
+	err := json.Unmarshal([]byte(arguments), &decoded)
+	if err == nil {
+		return decoded, nil
+	}
+
+	repaired := RepairToolArguments(arguments)
+	if json.Unmarshal([]byte(repaired), &decoded) != nil {
+		return nil, err
+	}
+	logger.Debug("tool arguments repaired", "arguments", arguments, "repaired", repaired)
+	return decoded, nil
+}
+
+// repairToolCalls replaces the arguments of the tool calls that are not valid JSON with their repaired version
+// (see RepairToolArguments), so the assistant message kept in the history is valid too.
+// It returns an error for the first tool call whose arguments can't be repaired.
+func repairToolCalls(toolCalls []openai.ChatCompletionMessageToolCall, logger *slog.Logger) error {
+	for i, toolCall := range toolCalls {
+		arguments := toolCall.Function.Arguments
+		if json.Valid([]byte(arguments)) {
+			continue
+		}
+		repaired := RepairToolArguments(arguments)
+		if !json.Valid([]byte(repaired)) {
+			var decoded map[string]any
+			err := json.Unmarshal([]byte(arguments), &decoded)
+			return fmt.Errorf("the arguments of %s are not valid JSON (%s): %w", toolCall.Function.Name, arguments, err)
+		}
+		logger.Debug("tool arguments repaired", "name", toolCall.Function.Name, "arguments", arguments, "repaired", repaired)
+		toolCalls[i].Function.Arguments = repaired
+	}
+	return nil
+}
+
+// RepairToolArguments applies lightweight fixes to the tool arguments emitted by small models:
+// it removes the markdown code fences (see StripCodeFences) and closes the unbalanced braces and brackets.
+// An empty result becomes an empty object.
+func RepairToolArguments(arguments string) string {
+	repaired := strings.TrimSpace(StripCodeFences(arguments))
+	if repaired == "" {
+		return "{}"
+	}
+
+	// Close the brackets left open, ignoring the ones in strings
+	var open []rune
+	inString, escaped := false, false
+	for _, r := range repaired {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case inString:
+		case r == '{', r == '[':
+			open = append(open, r)
+		case (r == '}' || r == ']') && len(open) > 0:
+			open = open[:len(open)-1]
+		}
+	}
+	if inString {
+		repaired += `"`
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == '{' {
+			repaired += "}"
+		} else {
+			repaired += "]"
+		}
+	}
+	return repaired
+}
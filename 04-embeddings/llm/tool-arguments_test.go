@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+func TestRepairToolArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      string
+	}{
+		{"valid", `{"a":1}`, `{"a":1}`},
+		{"code fences", "```json\n{\"a\":1}\n```", `{"a":1}`},
+		{"unclosed brace", `{"a":1`, `{"a":1}`},
+		{"unclosed bracket", `{"a":[1,2`, `{"a":[1,2]}`},
+		{"braces inside strings", `{"a":"{[","b":"}"`, `{"a":"{[","b":"}"}`},
+		{"unterminated string", `{"a":"b`, `{"a":"b"}`},
+		{"empty", "", "{}"},
+		{"blank", "  \n", "{}"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := RepairToolArguments(test.arguments); got != test.want {
+				t.Errorf("RepairToolArguments(%q) = %q, want %q", test.arguments, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRepairToolCalls(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	toolCalls := []openai.ChatCompletionMessageToolCall{
+		mockToolCall("call_1", "add", `{"a":1,"b":2}`),
+		mockToolCall("call_2", "add", "```json\n{\"a\":1,\"b\":2"),
+	}
+	if err := repairToolCalls(toolCalls, logger); err != nil {
+		t.Fatal(err)
+	}
+	for _, toolCall := range toolCalls {
+		if toolCall.Function.Arguments != `{"a":1,"b":2}` {
+			t.Errorf("arguments of %s = %q, want the repaired JSON", toolCall.ID, toolCall.Function.Arguments)
+		}
+	}
+
+	if err := repairToolCalls([]openai.ChatCompletionMessageToolCall{mockToolCall("call_3", "add", `{a:1}`)}, logger); err == nil {
+		t.Error("no error for arguments that can't be repaired")
+	}
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// ToolCallTrace is the JSON line written to ToolLoopOptions.Trace for each detected tool call.
+// Run two models on the same prompt and diff their traces to compare their tool calling.
+type ToolCallTrace struct {
+	Time      time.Time `json:"time"`
+	Model     string    `json:"model"`
+	Pass      int       `json:"pass"`
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Arguments string    `json:"arguments"`
+	LatencyMs int64     `json:"latency_ms"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// newToolCallTrace creates the trace of a tool call that ran in latency and returned err
+func newToolCallTrace(model string, pass int, toolCall openai.ChatCompletionMessageToolCall, latency time.Duration, err error) ToolCallTrace {
+	trace := ToolCallTrace{
+		Time:      time.Now().UTC(),
+		Model:     model,
+		Pass:      pass,
+		Id:        toolCall.ID,
+		Name:      toolCall.Function.Name,
+		Arguments: toolCall.Function.Arguments,
+		LatencyMs: latency.Milliseconds(),
+		Success:   err == nil,
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	return trace
+}
+
+// writeToolCallTrace writes the trace as a JSON line to w.
+// A tracing failure is logged but does not stop the tool loop.
+func writeToolCallTrace(w io.Writer, trace ToolCallTrace, logger *slog.Logger) {
+	if err := json.NewEncoder(w).Encode(trace); err != nil {
+		logger.Warn("failed to write the tool call trace", "error", err)
+	}
+}
@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// ToolHandler runs a tool with the arguments decoded from the tool call and returns the result for the model
+type ToolHandler func(ctx context.Context, arguments map[string]any) (string, error)
+
+// Tool is a tool definition given to the model and the handler running it
+type Tool struct {
+	Definition openai.ChatCompletionToolParam
+	Handler    ToolHandler
+}
+
+// ToolLoopOptions are the settings of RunToolLoop
+type ToolLoopOptions struct {
+	// MaxPasses is the maximum number of tool detection passes (0 means 1)
+	MaxPasses int
+	// Trace receives a JSON line per detected tool call (optional, see ToolCallTrace)
+	Trace io.Writer
+	// Concurrency is the maximum number of tool calls of a pass run at the same time (0 or 1 runs them sequentially).
+	// Use it only when the tools are independent.
+	Concurrency int
+	// DryRun receives the detected tool calls (a line per call with its name and arguments) when set:
+	// the model gets a stub result instead of running the handlers, to iterate on prompts without calling the real tools
+	DryRun io.Writer
+	// Detection are the generation settings of the tool detection requests
+	// (nil means DefaultDetectionOptions())
+	Detection *GenOptions
+	// Synthesis are the generation settings of the final answer of AnswerWithTools
+	Synthesis GenOptions
+	// Logger receives the detected tool calls and their failures (nil discards them)
+	Logger *slog.Logger
+}
+
+// DefaultDetectionOptions returns the generation settings making the tool detection deterministic
+// (temperature 0, seed 0), a new value at every call so the callers can change it
+func DefaultDetectionOptions() GenOptions {
+	seed := int64(0)
+	return GenOptions{Preset: Deterministic, Seed: &seed}
+}
+
+// dryRunResult is the tool result given to the model in dry-run mode
+const dryRunResult = "dry run: the tool was not executed"
+
+// toolCallResult is the outcome of a tool call run by runToolCalls
+type toolCallResult struct {
+	content string
+	err     error
+	latency time.Duration
+}
+
+// RunToolLoop asks the model to detect the tool calls, runs them and adds their results to the messages,
+// pass after pass, until the model does not call any tool or MaxPasses is reached.
+// It returns the messages completed with the tool calls and results,
+// ready for the final chat completion.
+func RunToolLoop(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, tools []Tool, opts ToolLoopOptions) ([]openai.ChatCompletionMessageParamUnion, error) {
+	handlers := make(map[string]ToolHandler, len(tools))
+	definitions := make([]openai.ChatCompletionToolParam, 0, len(tools))
+	for _, tool := range tools {
+		handlers[tool.Definition.Function.Name] = tool.Handler
+		definitions = append(definitions, tool.Definition)
+	}
+
+	logger := loggerOrDiscard(opts.Logger)
+	detection := DefaultDetectionOptions()
+	if opts.Detection != nil {
+		detection = *opts.Detection
+	}
+	if detection.Logger == nil {
+		detection.Logger = opts.Logger
+	}
+
+	for pass := 1; pass <= max(opts.MaxPasses, 1); pass++ {
+		params := openai.ChatCompletionNewParams{
+			Messages:          messages,
+			ParallelToolCalls: openai.Bool(true),
+			Tools:             definitions,
+			Model:             model,
+		}
+		detection.Apply(&params)
+
+		// Make the chat completion request to detect the tools
+		message, err := detectToolCalls(ctx, client, params)
+		if err != nil {
+			return messages, err
+		}
+
+		// Ask the model once more if the arguments can't be repaired
+		if err := repairToolCalls(message.ToolCalls, logger); err != nil {
+			logger.Warn("malformed tool arguments, retrying", "pass", pass, "error", err)
+			params.Messages = append(slices.Clone(messages), openai.SystemMessage(
+				fmt.Sprintf("Your tool call is invalid: %v. Call the tool again with valid JSON arguments.", err),
+			))
+			message, err = detectToolCalls(ctx, client, params)
+			if err != nil {
+				return messages, err
+			}
+			// the tool calls still invalid fail in runToolCall
+			if err := repairToolCalls(message.ToolCalls, logger); err != nil {
+				logger.Warn("malformed tool arguments after the retry", "pass", pass, "error", err)
+			}
+		}
+
+		detectedToolCalls := message.ToolCalls
+		if len(detectedToolCalls) == 0 {
+			logger.Debug("no tool call detected", "pass", pass)
+			return messages, nil
+		}
+		logger.Info("tool calls detected", "pass", pass, "count", len(detectedToolCalls))
+
+		messages = append(messages, message.ToParam())
+		results := runToolCalls(ctx, handlers, detectedToolCalls, opts, logger)
+		for i, toolCall := range detectedToolCalls {
+			if opts.Trace != nil {
+				writeToolCallTrace(opts.Trace, newToolCallTrace(model, pass, toolCall, results[i].latency, results[i].err), logger)
+			}
+			messages = append(messages, openai.ToolMessage(results[i].content, toolCall.ID))
+		}
+	}
+	return messages, nil
+}
+
+// AnswerWithTools runs the tool loop, then streams to w the final answer generated
+// with opts.Synthesis from the messages completed with the tool results.
+// It returns the full answer.
+func AnswerWithTools(ctx context.Context, client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, tools []Tool, opts ToolLoopOptions, w io.Writer) (string, error) {
+	messages, err := RunToolLoop(ctx, client, model, messages, tools, opts)
+	if err != nil {
+		return "", err
+	}
+	synthesis := opts.Synthesis
+	if synthesis.Logger == nil {
+		synthesis.Logger = opts.Logger
+	}
+	return Stream(ctx, client, model, messages, synthesis, w)
+}
+
+// detectToolCalls makes the chat completion request and returns the message of the first choice
+func detectToolCalls(ctx context.Context, client openai.Client, params openai.ChatCompletionNewParams) (openai.ChatCompletionMessage, error) {
+	completion, err := client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return openai.ChatCompletionMessage{}, err
+	}
+	if len(completion.Choices) == 0 {
+		return openai.ChatCompletionMessage{}, errors.New("the completion contains no choice")
+	}
+	return completion.Choices[0].Message, nil
+}
+
+// runToolCalls runs the tool calls with at most opts.Concurrency calls at the same time
+// and returns their results in the order of the calls
+func runToolCalls(ctx context.Context, handlers map[string]ToolHandler, toolCalls []openai.ChatCompletionMessageToolCall, opts ToolLoopOptions, logger *slog.Logger) []toolCallResult {
+	results := make([]toolCallResult, len(toolCalls))
+	if opts.DryRun != nil {
+		for i, toolCall := range toolCalls {
+			logger.Info("dry run tool call", "name", toolCall.Function.Name, "arguments", toolCall.Function.Arguments)
+			fmt.Fprintf(opts.DryRun, "%s %s\n", toolCall.Function.Name, toolCall.Function.Arguments)
+			results[i] = toolCallResult{content: dryRunResult}
+		}
+		return results
+	}
+
+	run := func(i int) {
+		start := time.Now()
+		content, err := runToolCall(ctx, handlers, toolCalls[i], logger)
+		results[i] = toolCallResult{content: content, err: err, latency: time.Since(start)}
+	}
+
+	if opts.Concurrency <= 1 {
+		for i := range toolCalls {
+			run(i)
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, opts.Concurrency)
+	for i := range toolCalls {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			run(i)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runToolCall runs the handler of the tool call and returns its result.
+// If the call failed, the result is an error message for the model and the error is returned too.
+func runToolCall(ctx context.Context, handlers map[string]ToolHandler, toolCall openai.ChatCompletionMessageToolCall, logger *slog.Logger) (string, error) {
+	handler, ok := handlers[toolCall.Function.Name]
+	if !ok {
+		logger.Warn("unknown tool", "name", toolCall.Function.Name)
+		err := fmt.Errorf("unknown tool %s", toolCall.Function.Name)
+		return "error: " + err.Error(), err
+	}
+
+	arguments, err := decodeToolArguments(toolCall.Function.Arguments, logger)
+	if err != nil {
+		logger.Warn("invalid tool arguments", "name", toolCall.Function.Name, "arguments", toolCall.Function.Arguments)
+		err = fmt.Errorf("invalid arguments: %w", err)
+		return "error: " + err.Error(), err
+	}
+
+	result, err := handler(ctx, arguments)
+	if err != nil {
+		logger.Warn("tool call failed", "name", toolCall.Function.Name, "error", err)
+		return "error: " + err.Error(), err
+	}
+	logger.Debug("tool called", "name", toolCall.Function.Name, "arguments", toolCall.Function.Arguments)
+	return result, nil
+}
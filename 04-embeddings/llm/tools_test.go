@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+// addTool returns a tool adding the numbers a and b, counting its calls
+func addTool(calls *int) Tool {
+	return Tool{
+		Definition: openai.ChatCompletionToolParam{
+			Function: openai.FunctionDefinitionParam{
+				Name: "add",
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]any{
+						"a": map[string]string{"type": "number"},
+						"b": map[string]string{"type": "number"},
+					},
+					"required": []string{"a", "b"},
+				},
+			},
+		},
+		Handler: func(ctx context.Context, arguments map[string]any) (string, error) {
+			*calls++
+			a, _ := arguments["a"].(float64)
+			b, _ := arguments["b"].(float64)
+			return fmt.Sprint(a + b), nil
+		},
+	}
+}
+
+// mockToolCall returns a tool call of the model
+func mockToolCall(id, name, arguments string) openai.ChatCompletionMessageToolCall {
+	return openai.ChatCompletionMessageToolCall{
+		ID:       id,
+		Function: openai.ChatCompletionMessageToolCallFunction{Name: name, Arguments: arguments},
+	}
+}
+
+// requestMessages decodes the messages of a chat completion request
+func requestMessages(t *testing.T, request MockRequest) []map[string]any {
+	t.Helper()
+	var body struct {
+		Messages []map[string]any `json:"messages"`
+	}
+	if err := json.Unmarshal(request.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Messages
+}
+
+func TestRunToolLoop(t *testing.T) {
+	tests := []struct {
+		name       string
+		toolCall   openai.ChatCompletionMessageToolCall
+		dryRun     bool
+		wantCalls  int
+		wantResult string
+		wantDryRun string
+	}{
+		{"tool called", mockToolCall("call_1", "add", `{"a":1,"b":2}`), false, 1, "3", ""},
+		{"repaired arguments", mockToolCall("call_1", "add", "```json\n{\"a\":1,\"b\":2"), false, 1, "3", ""},
+		{"unknown tool", mockToolCall("call_1", "multiply", `{"a":1,"b":2}`), false, 0, "error: unknown tool multiply", ""},
+		{"dry run", mockToolCall("call_1", "add", `{"a":1,"b":2}`), true, 0, dryRunResult, "add {\"a\":1,\"b\":2}\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := &MockTransport{Completions: []MockCompletion{
+				{ToolCalls: []openai.ChatCompletionMessageToolCall{test.toolCall}},
+				{Content: "done"},
+			}}
+			calls := 0
+			var dryRun strings.Builder
+			opts := ToolLoopOptions{MaxPasses: 2}
+			if test.dryRun {
+				opts.DryRun = &dryRun
+			}
+
+			messages, err := RunToolLoop(context.Background(), NewMockClient(mock), "mock-model",
+				[]openai.ChatCompletionMessageParamUnion{openai.UserMessage("1 + 2?")}, []Tool{addTool(&calls)}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls != test.wantCalls {
+				t.Errorf("%d handler calls, want %d", calls, test.wantCalls)
+			}
+			if dryRun.String() != test.wantDryRun {
+				t.Errorf("dry run = %q, want %q", dryRun.String(), test.wantDryRun)
+			}
+			// the user message, the tool call and the tool result
+			if len(messages) != 3 {
+				t.Fatalf("%d messages, want 3", len(messages))
+			}
+
+			requests := mock.Requests()
+			if len(requests) != 2 {
+				t.Fatalf("%d requests, want 2", len(requests))
+			}
+			// the second pass sends the tool result to the model
+			sent := requestMessages(t, requests[1])
+			result := sent[len(sent)-1]
+			if result["role"] != "tool" || result["tool_call_id"] != "call_1" || result["content"] != test.wantResult {
+				t.Errorf("tool result message = %v, want the content %q", result, test.wantResult)
+			}
+			// the assistant message of the history has valid JSON arguments
+			toolCalls, _ := sent[len(sent)-2]["tool_calls"].([]any)
+			if len(toolCalls) != 1 {
+				t.Fatalf("assistant message = %v, want a tool call", sent[len(sent)-2])
+			}
+			function := toolCalls[0].(map[string]any)["function"].(map[string]any)
+			if arguments, _ := function["arguments"].(string); !json.Valid([]byte(arguments)) {
+				t.Errorf("arguments in the history = %q, want valid JSON", arguments)
+			}
+		})
+	}
+}
@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
+	"embeddings-demo/llm"
 	"embeddings-demo/rag"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
+	"time"
 )
 
 var chunks = []string{
@@ -47,56 +47,52 @@ var chunks = []string{
 }
 
 // MODEL_RUNNER_BASE_URL=http://localhost:12434 go run main.go
+// MODEL_RUNNER_LLM_EMBEDDINGS and MODEL_RUNNER_LLM_CHAT are optional (see llm.DefaultEmbeddingsModel and llm.DefaultChatModel)
 func main() {
 	ctx := context.Background()
 
-	llmURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
-	embeddingsModel := "ai/mxbai-embed-large"
-	chatModel := "ai/qwen2.5:0.5B-F16"
+	config, err := llm.LoadConfig()
+	if err != nil {
+		log.Fatal("😡: ", err)
+	}
+	embeddingsModel := config.EmbeddingsModel
+	chatModel := config.ChatModel
+	clientConfig := config.ClientConfig()
 
-	client := openai.NewClient(
-		option.WithBaseURL(llmURL),
-		option.WithAPIKey(""),
-	)
+	if err := llm.PingModelRunner(ctx, clientConfig); err != nil {
+		log.Fatal("😡: ", err)
+	}
+
+	for _, model := range []string{embeddingsModel, chatModel} {
+		if err := llm.EnsureModel(ctx, clientConfig, model, 10*time.Minute); err != nil {
+			log.Fatal("😡: ", err)
+		}
+	}
+
+	client := llm.NewClient(clientConfig)
 
 	// -------------------------------------------------
 	// Create a vector store
 	// -------------------------------------------------
-	store := rag.MemoryVectorStore{
-		Records: make(map[string]rag.VectorRecord),
-	}
+	store := rag.NewMemoryVectorStore()
 
 	// -------------------------------------------------
 	// Create and save the embeddings from the chunks
 	// -------------------------------------------------
 	fmt.Println("⏳ Creating the embeddings...")
 
-	for _, chunk := range chunks {
-		embeddingsResponse, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
-			Input: openai.EmbeddingNewParamsInputUnion{
-				OfString: openai.String(chunk),
-			},
-			Model: embeddingsModel,
-		})
-
-		if err != nil {
-			fmt.Println(err)
-		} else {
-			_, errSave := store.Save(rag.VectorRecord{
-				Prompt:    chunk,
-				Embedding: embeddingsResponse.Data[0].Embedding,
-			})
-			if errSave != nil {
-				fmt.Println("😡:", errSave)
-			}
-		}
+	// the same prefixes are used for the chunks and the question (ex: the mxbai-embed-large query instruction)
+	embedOptions := rag.EmbedOptionsForModel(embeddingsModel)
+
+	if err := rag.IngestChunks(ctx, client, embeddingsModel, store, chunks, rag.IngestOptions{Embed: embedOptions}); err != nil {
+		fmt.Println("😡:", err)
 	}
 
-	fmt.Println("✋", "Embeddings created, total of records", len(store.Records))
+	fmt.Println("✋", "Embeddings created, total of records", store.Len())
 	fmt.Println()
 
 	// -------------------------------------------------
-	// Search for similarities
+	// User question
 	// -------------------------------------------------
 	//userQuestion := "Tell me about the English series called The Avengers?"
 	// userQuestion := "Who is John Steed?"
@@ -104,69 +100,30 @@ func main() {
 	// userQuestion := "Who is Tara King?"
 	// userQuestion := "Who is Mother?"
 
-	fmt.Println("⏳ Searching for similarities...")
-
-	// -------------------------------------------------
-	// Create embedding from the user question
-	// -------------------------------------------------
-	embeddingsResponse, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
-		Input: openai.EmbeddingNewParamsInputUnion{
-			OfString: openai.String(userQuestion),
-		},
-		Model: embeddingsModel,
-	})
-	if err != nil {
-		log.Fatal("😡:", err)
-	}
-
 	// -------------------------------------------------
-	// Create a vector record from the user embedding
+	// Search for similarities and generate completion
 	// -------------------------------------------------
-	embeddingFromUserQuestion := rag.VectorRecord{
-		Embedding: embeddingsResponse.Data[0].Embedding,
+	queryOptions := rag.QueryOptions{
+		Instructions: `You are a useful AI agent expert with TV series. 
+	Use only the following documents to answer:`,
+		// Prefix every document with its relevance (cosine similarity) so the model can weigh them
+		// IncludeScores: true,
+		Embed: embedOptions,
 	}
 
-	similarities, _ := store.SearchTopNSimilarities(embeddingFromUserQuestion, 0.6, 2)
-
-	documentsContent := "Documents:\n"
-
-	for _, similarity := range similarities {
-		fmt.Println("✅ CosineSimilarity:", similarity.CosineSimilarity, "Chunk:", similarity.Prompt)
-		documentsContent += similarity.Prompt
-	}
-	documentsContent += "\n"
-	fmt.Println("✋", "Similarities found, total of records", len(similarities))
-	fmt.Println()
-
-	// -------------------------------------------------
-	// Generate completion
-	// -------------------------------------------------
-	messages := []openai.ChatCompletionMessageParamUnion{
-		openai.SystemMessage(`You are a useful AI agent expert with TV series. 
-		Use only the following documents to answer:`),
-		openai.SystemMessage(documentsContent),
-		openai.UserMessage(userQuestion),
-	}
-
-	param := openai.ChatCompletionNewParams{
-		Messages:    messages,
-		Model:       chatModel,
-		Temperature: openai.Opt(0.0),
+	_, sources, err := rag.Query(ctx, client, embeddingsModel, chatModel, store, userQuestion, 2, 0.6, queryOptions, os.Stdout)
+	if errors.Is(err, rag.ErrEmbeddingFailed) {
+		fmt.Println("😡 The question could not be embedded, is the embeddings model running?", err)
+		return
 	}
-
-	stream := client.Chat.Completions.NewStreaming(ctx, param)
-
-	for stream.Next() {
-		chunk := stream.Current()
-		// Stream each chunk as it arrives
-		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
-			fmt.Print(chunk.Choices[0].Delta.Content)
-		}
-	}
-
-	if err := stream.Err(); err != nil {
+	if err != nil {
 		log.Fatalln("😡:", err)
 	}
 
 	fmt.Println()
+	fmt.Println()
+	fmt.Println("📚 Sources:")
+	for _, source := range sources {
+		fmt.Println("✅ CosineSimilarity:", source.Score, "Weight:", source.Weight, "Id:", source.Id)
+	}
 }
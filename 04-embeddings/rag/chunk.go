@@ -0,0 +1,62 @@
+package rag
+
+import (
+	"strings"
+)
+
+// ChunkText splits the text in chunks of chunkSize characters (runes),
+// each chunk starting with the last overlap characters of the previous one
+func ChunkText(text string, chunkSize, overlap int) []string {
+	if chunkSize <= 0 {
+		return nil
+	}
+	if overlap < 0 || overlap >= chunkSize {
+		overlap = 0
+	}
+
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); start += chunkSize - overlap {
+		end := min(start+chunkSize, len(runes))
+		chunks = append(chunks, string(runes[start:end]))
+		if end == len(runes) {
+			break
+		}
+	}
+	return chunks
+}
+
+// SplitParagraphs splits the text on blank lines and drops the empty paragraphs
+func SplitParagraphs(text string) []string {
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs
+}
+
+// SplitMarkdownSections splits a markdown document before every heading (# Title),
+// each section keeps its heading
+func SplitMarkdownSections(text string) []string {
+	var sections []string
+	var section strings.Builder
+
+	flush := func() {
+		if content := strings.TrimSpace(section.String()); content != "" {
+			sections = append(sections, content)
+		}
+		section.Reset()
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			flush()
+		}
+		section.WriteString(line)
+		section.WriteString("\n")
+	}
+	flush()
+	return sections
+}
@@ -14,13 +14,50 @@ func dotProduct(v1 []float64, v2 []float64) float64 {
 	return sum
 }
 
-// CosineSimilarity calculates the cosine similarity between two vectors
+// CosineSimilarity calculates the cosine similarity between two vectors.
+// The result is in [-1, 1]: 1 for vectors with the same direction, 0 for orthogonal vectors
+// and -1 for opposite vectors. It returns 0 if a vector is a zero vector
+// or if the vectors do not have the same length.
+//
+// The dot product and the norms are computed in a single pass, unrolled 4 times
+// with separate accumulators, so the CPU can run the independent multiplications in parallel.
 func CosineSimilarity(v1, v2 []float64) float64 {
-	// Calculate the cosine distance between two vectors
-	product := dotProduct(v1, v2)
+	if len(v1) != len(v2) {
+		return 0.0
+	}
 
-	norm1 := math.Sqrt(dotProduct(v1, v1))
-	norm2 := math.Sqrt(dotProduct(v2, v2))
+	var dot0, dot1, dot2, dot3 float64
+	var normA0, normA1, normA2, normA3 float64
+	var normB0, normB1, normB2, normB3 float64
+
+	i := 0
+	for ; i <= len(v1)-4; i += 4 {
+		// Slice the 4 elements once to remove the bounds checks of the loop body
+		a := v1[i : i+4 : i+4]
+		b := v2[i : i+4 : i+4]
+		dot0 += a[0] * b[0]
+		dot1 += a[1] * b[1]
+		dot2 += a[2] * b[2]
+		dot3 += a[3] * b[3]
+		normA0 += a[0] * a[0]
+		normA1 += a[1] * a[1]
+		normA2 += a[2] * a[2]
+		normA3 += a[3] * a[3]
+		normB0 += b[0] * b[0]
+		normB1 += b[1] * b[1]
+		normB2 += b[2] * b[2]
+		normB3 += b[3] * b[3]
+	}
+	// Remaining elements
+	for ; i < len(v1); i++ {
+		dot0 += v1[i] * v2[i]
+		normA0 += v1[i] * v1[i]
+		normB0 += v2[i] * v2[i]
+	}
+
+	product := (dot0 + dot1) + (dot2 + dot3)
+	norm1 := math.Sqrt((normA0 + normA1) + (normA2 + normA3))
+	norm2 := math.Sqrt((normB0 + normB1) + (normB2 + normB3))
 	if norm1 <= 0.0 || norm2 <= 0.0 {
 		// Handle potential division by zero
 		return 0.0
@@ -28,6 +65,69 @@ func CosineSimilarity(v1, v2 []float64) float64 {
 	return product / (norm1 * norm2)
 }
 
+// Normalize returns a copy of the vector scaled to a length of 1
+// (the cosine similarity of two normalized vectors is their dot product).
+// A zero vector is returned unchanged.
+func Normalize(v []float64) []float64 {
+	normalized := make([]float64, len(v))
+	norm := math.Sqrt(dotProduct(v, v))
+	if norm <= 0.0 {
+		copy(normalized, v)
+		return normalized
+	}
+	for i := range v {
+		normalized[i] = v[i] / norm
+	}
+	return normalized
+}
+
+// SoftmaxWeights sets the Weight of every search result to the softmax of the scores,
+// so the weights sum to 1. A low temperature (ex: 0.1) increases the gap between the results,
+// a temperature <= 0 is treated as 1.
+func SoftmaxWeights(results []SearchResult, temperature float64) []SearchResult {
+	if len(results) == 0 {
+		return results
+	}
+	if temperature <= 0 {
+		temperature = 1.0
+	}
+
+	// subtract the max score for numerical stability
+	maxScore := results[0].Score
+	for _, result := range results {
+		maxScore = math.Max(maxScore, result.Score)
+	}
+
+	sum := 0.0
+	for i := range results {
+		results[i].Weight = math.Exp((results[i].Score - maxScore) / temperature)
+		sum += results[i].Weight
+	}
+	for i := range results {
+		results[i].Weight /= sum
+	}
+	return results
+}
+
+// SimilarityMatrix returns the pairwise cosine similarity matrix of the records.
+// The matrix is symmetric and its diagonal is 1.0.
+func SimilarityMatrix(records []VectorRecord) [][]float64 {
+	matrix := make([][]float64, len(records))
+	for i := range records {
+		matrix[i] = make([]float64, len(records))
+	}
+
+	for i := range records {
+		matrix[i][i] = 1.0
+		for j := i + 1; j < len(records); j++ {
+			similarity := CosineSimilarity(records[i].Embedding, records[j].Embedding)
+			matrix[i][j] = similarity
+			matrix[j][i] = similarity
+		}
+	}
+	return matrix
+}
+
 
 func GetTopNVectorRecords(records []VectorRecord, max int) []VectorRecord {
 	// Sort the records slice in descending order based on CosineDistance
@@ -0,0 +1,72 @@
+package rag
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name   string
+		v1, v2 []float64
+		want   float64
+	}{
+		{"orthogonal", []float64{1, 0}, []float64{0, 1}, 0},
+		{"identical", []float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		{"opposite", []float64{1, 2, 3}, []float64{-1, -2, -3}, -1},
+		{"zero vector", []float64{0, 0, 0}, []float64{1, 2, 3}, 0},
+		{"length mismatch", []float64{1, 2, 3}, []float64{1, 2}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CosineSimilarity(test.v1, test.v2); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("CosineSimilarity(%v, %v) = %v, want %v", test.v1, test.v2, got, test.want)
+			}
+		})
+	}
+}
+
+// naiveCosineSimilarity is the cosine similarity computed with a loop per sum
+func naiveCosineSimilarity(v1, v2 []float64) float64 {
+	var product, norm1, norm2 float64
+	for i := range v1 {
+		product += v1[i] * v2[i]
+	}
+	for i := range v1 {
+		norm1 += v1[i] * v1[i]
+	}
+	for i := range v2 {
+		norm2 += v2[i] * v2[i]
+	}
+	return product / (math.Sqrt(norm1) * math.Sqrt(norm2))
+}
+
+func TestCosineSimilarityUnrolled(t *testing.T) {
+	// the lengths that are not a multiple of 4 go through the loop of the remaining elements
+	for _, length := range []int{1, 2, 3, 5, 6, 7, 9, 383, 1021} {
+		v1, v2 := RandomVector(length, 1), RandomVector(length, 2)
+		got, want := CosineSimilarity(v1, v2), naiveCosineSimilarity(v1, v2)
+		if math.Abs(got-want) > 1e-12 {
+			t.Errorf("length %d: CosineSimilarity = %v, want %v", length, got, want)
+		}
+	}
+}
+
+// go test ./rag -run '^$' -bench CosineSimilarity
+func BenchmarkCosineSimilarity(b *testing.B) {
+	v1, v2 := RandomVector(1024, 1), RandomVector(1024, 2)
+	for _, similarity := range []struct {
+		name string
+		fn   func(v1, v2 []float64) float64
+	}{
+		{"naive", naiveCosineSimilarity},
+		{"unrolled", CosineSimilarity},
+	} {
+		b.Run(similarity.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				similarity.fn(v1, v2)
+			}
+		})
+	}
+}
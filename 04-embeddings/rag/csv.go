@@ -0,0 +1,157 @@
+package rag
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// csvHeader is the first row of the CSV files:
+// the embeddings are lists of floats separated by spaces, the multi-vector embeddings are separated by semicolons
+// and the metadata is a JSON object
+var csvHeader = []string{"id", "prompt", "embedding", "raw_embedding", "embeddings", "metadata", "token_count"}
+
+// csvLegacyHeader is the header of the CSV files written before the other fields were exported
+var csvLegacyHeader = []string{"id", "prompt", "embedding"}
+
+// ExportCSV writes the records of the store as CSV rows (id, prompt, embedding, raw_embedding, embeddings, metadata, token_count),
+// the embedding being a list of floats separated by spaces (ex: pandas: df.embedding.str.split()),
+// the multi-vector embeddings being separated by semicolons and the metadata being JSON encoded.
+// The prompts containing commas, quotes or newlines are quoted.
+func (mvs *MemoryVectorStore) ExportCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	records, err := mvs.GetAll()
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(records, func(a, b VectorRecord) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	for _, record := range records {
+		embeddings := make([]string, len(record.Embeddings))
+		for i, embedding := range record.Embeddings {
+			embeddings[i] = formatCSVEmbedding(embedding)
+		}
+		metadata := ""
+		if len(record.Metadata) > 0 {
+			data, err := json.Marshal(record.Metadata)
+			if err != nil {
+				return err
+			}
+			metadata = string(data)
+		}
+		if err := writer.Write([]string{
+			record.Id,
+			record.Prompt,
+			formatCSVEmbedding(record.Embedding),
+			formatCSVEmbedding(record.RawEmbedding),
+			strings.Join(embeddings, ";"),
+			metadata,
+			strconv.Itoa(record.TokenCount),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportCSV saves in the store the records read from CSV rows written by ExportCSV
+// (the files with only the id, prompt and embedding columns are accepted too)
+func (mvs *MemoryVectorStore) ImportCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(header, csvHeader) && !slices.Equal(header, csvLegacyHeader) {
+		return fmt.Errorf("unexpected CSV header %v, expected %v", header, csvHeader)
+	}
+
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		record, err := parseCSVRecord(fields)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		if _, err := mvs.Save(record); err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+	}
+}
+
+// parseCSVRecord returns the record of the fields of a CSV row
+func parseCSVRecord(fields []string) (VectorRecord, error) {
+	embedding, err := parseCSVEmbedding(fields[2])
+	if err != nil {
+		return VectorRecord{}, err
+	}
+	record := VectorRecord{Id: fields[0], Prompt: fields[1], Embedding: embedding}
+	if len(fields) == len(csvLegacyHeader) {
+		return record, nil
+	}
+
+	if fields[3] != "" {
+		if record.RawEmbedding, err = parseCSVEmbedding(fields[3]); err != nil {
+			return VectorRecord{}, err
+		}
+	}
+	if fields[4] != "" {
+		for value := range strings.SplitSeq(fields[4], ";") {
+			embedding, err := parseCSVEmbedding(value)
+			if err != nil {
+				return VectorRecord{}, err
+			}
+			record.Embeddings = append(record.Embeddings, embedding)
+		}
+	}
+	if fields[5] != "" {
+		if err := json.Unmarshal([]byte(fields[5]), &record.Metadata); err != nil {
+			return VectorRecord{}, fmt.Errorf("metadata: %w", err)
+		}
+	}
+	if record.TokenCount, err = strconv.Atoi(fields[6]); err != nil {
+		return VectorRecord{}, err
+	}
+	return record, nil
+}
+
+// formatCSVEmbedding returns the floats of the embedding separated by spaces
+func formatCSVEmbedding(embedding []float64) string {
+	values := make([]string, len(embedding))
+	for i, value := range embedding {
+		values[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return strings.Join(values, " ")
+}
+
+// parseCSVEmbedding parses the floats separated by spaces of an embedding
+func parseCSVEmbedding(field string) ([]float64, error) {
+	embedding := []float64{}
+	for value := range strings.FieldsSeq(field) {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		embedding = append(embedding, number)
+	}
+	return embedding, nil
+}
@@ -0,0 +1,65 @@
+package rag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	store := &MemoryVectorStore{Normalize: true}
+	records := []VectorRecord{
+		{
+			Id:         "cats",
+			Prompt:     "Cats purr, \"meow\"\nand sleep.",
+			Embedding:  []float64{3, 4},
+			Embeddings: [][]float64{{0, 2}, {1.5, 0}},
+			Metadata:   map[string]string{"source": "cats.md", "note": "a, b; \"c\""},
+			TokenCount: 42,
+		},
+		{Id: "dogs", Prompt: "Dogs bark.", Embedding: []float64{0.1, -0.2}},
+	}
+	for _, record := range records {
+		if _, err := store.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var csv strings.Builder
+	if err := store.ExportCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	imported := &MemoryVectorStore{Normalize: true}
+	if err := imported.ImportCSV(strings.NewReader(csv.String())); err != nil {
+		t.Fatalf("ImportCSV(%s) = %v", csv.String(), err)
+	}
+
+	if imported.Len() != len(records) {
+		t.Fatalf("%d records imported, want %d", imported.Len(), len(records))
+	}
+	for _, record := range records {
+		want, _ := store.Get(record.Id)
+		got, ok := imported.Get(record.Id)
+		if !ok {
+			t.Fatalf("record %s not imported", record.Id)
+		}
+		// an empty list of embeddings is exported like a nil one
+		if len(want.Embeddings) == 0 {
+			want.Embeddings = nil
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("imported record = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestImportCSVLegacyHeader(t *testing.T) {
+	store := NewMemoryVectorStore()
+	if err := store.ImportCSV(strings.NewReader("id,prompt,embedding\ncats,Cats purr.,1 0\n")); err != nil {
+		t.Fatal(err)
+	}
+	record, ok := store.Get("cats")
+	if !ok || record.Prompt != "Cats purr." || !reflect.DeepEqual(record.Embedding, []float64{1, 0}) {
+		t.Errorf("record = %+v, want the record of the row", record)
+	}
+}
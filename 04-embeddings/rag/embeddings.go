@@ -0,0 +1,194 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// DefaultEmbeddingsTimeout is the maximum duration of an embeddings request when EmbedOptions.Timeout is 0
+const DefaultEmbeddingsTimeout = 30 * time.Second
+
+// ErrEmbeddingsTimeout is returned when an embeddings request lasts more than its timeout (see EmbedOptions)
+var ErrEmbeddingsTimeout = errors.New("the embeddings request timed out")
+
+// EmbedOptions are the settings of the embedding helpers (the zero value uses the defaults)
+type EmbedOptions struct {
+	// Timeout is the maximum duration of an embeddings request
+	// (0 means DefaultEmbeddingsTimeout, a negative value means no timeout)
+	Timeout time.Duration
+	// QueryPrefix is prepended to the questions embedded by EmbedQuery: some models retrieve better
+	// with an instruction (ex: "Represent this sentence for searching relevant passages: " for mxbai-embed-large)
+	QueryPrefix string
+	// DocumentPrefix is prepended to the chunks embedded by EmbedDocuments and the ingestion helpers
+	DocumentPrefix string
+	// Logger receives the failures of EmbedConcurrent and the progress of ReEmbed (nil discards them)
+	Logger *slog.Logger
+}
+
+// mxbaiQueryPrefix is the instruction of the mxbai-embed-large questions
+const mxbaiQueryPrefix = "Represent this sentence for searching relevant passages: "
+
+// EmbedOptionsForModel returns the options with the prefixes expected by the embeddings model:
+// mxbai-embed-large retrieves better when the questions (not the documents) are prefixed with an instruction.
+// Use the same options to ingest the documents and to embed the questions.
+func EmbedOptionsForModel(model string) EmbedOptions {
+	if strings.Contains(model, "mxbai") {
+		return EmbedOptions{QueryPrefix: mxbaiQueryPrefix}
+	}
+	return EmbedOptions{}
+}
+
+// timeout returns the timeout of the embeddings requests, 0 if there is none
+func (opts EmbedOptions) timeout() time.Duration {
+	switch {
+	case opts.Timeout == 0:
+		return DefaultEmbeddingsTimeout
+	case opts.Timeout < 0:
+		return 0
+	}
+	return opts.Timeout
+}
+
+// EmbedQuery creates the embedding of a question (search query) prefixed with opts.QueryPrefix
+func EmbedQuery(ctx context.Context, client openai.Client, model string, query string, opts EmbedOptions) ([]float64, error) {
+	return Embed(ctx, client, model, opts.QueryPrefix+query, opts)
+}
+
+// EmbedDocuments creates the embeddings of the chunks prefixed with opts.DocumentPrefix (see EmbedBatch)
+func EmbedDocuments(ctx context.Context, client openai.Client, model string, chunks []string, opts EmbedOptions) ([][]float64, error) {
+	if opts.DocumentPrefix == "" {
+		return EmbedBatch(ctx, client, model, chunks, opts)
+	}
+	prefixed := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prefixed[i] = opts.DocumentPrefix + chunk
+	}
+	return EmbedBatch(ctx, client, model, prefixed, opts)
+}
+
+// Embed creates the embedding of the text.
+// The request is cancelled after the timeout of the options and the error then wraps ErrEmbeddingsTimeout.
+func Embed(ctx context.Context, client openai.Client, model string, text string, opts EmbedOptions) ([]float64, error) {
+	requestCtx, cancel := withEmbeddingsTimeout(ctx, opts)
+	defer cancel()
+
+	embeddingsResponse, err := client.Embeddings.New(requestCtx, openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfString: openai.String(text),
+		},
+		Model: model,
+	})
+	if err != nil {
+		return nil, embeddingsError(ctx, err, opts)
+	}
+	return FirstEmbedding(embeddingsResponse)
+}
+
+// withEmbeddingsTimeout returns a context cancelled after the timeout of the options
+func withEmbeddingsTimeout(ctx context.Context, opts EmbedOptions) (context.Context, context.CancelFunc) {
+	if opts.timeout() == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, opts.timeout())
+}
+
+// embeddingsError wraps ErrEmbeddingsTimeout if the request failed because of the timeout of the options
+// (and not because the parent context is done)
+func embeddingsError(ctx context.Context, err error, opts EmbedOptions) error {
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("%w (%s): %v", ErrEmbeddingsTimeout, opts.timeout(), err)
+	}
+	return fmt.Errorf("failed to create the embedding: %w", err)
+}
+
+// EmbedBatch creates the embeddings of all the inputs with a single request.
+// The embeddings are returned in the same order as the inputs.
+func EmbedBatch(ctx context.Context, client openai.Client, model string, inputs []string, opts EmbedOptions) ([][]float64, error) {
+	if len(inputs) == 0 {
+		return [][]float64{}, nil
+	}
+
+	requestCtx, cancel := withEmbeddingsTimeout(ctx, opts)
+	defer cancel()
+
+	embeddingsResponse, err := client.Embeddings.New(requestCtx, openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfArrayOfStrings: inputs,
+		},
+		Model: model,
+	})
+	if err != nil {
+		return nil, embeddingsError(ctx, err, opts)
+	}
+
+	if len(embeddingsResponse.Data) != len(inputs) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(embeddingsResponse.Data))
+	}
+
+	// the embeddings are not guaranteed to be in the input order, use their index
+	embeddings := make([][]float64, len(inputs))
+	for _, data := range embeddingsResponse.Data {
+		if data.Index < 0 || int(data.Index) >= len(inputs) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		if embeddings[data.Index] != nil {
+			return nil, fmt.Errorf("duplicated embedding index %d", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	return embeddings, nil
+}
+
+// FirstEmbedding returns the first embedding of the response,
+// or ErrEmptyEmbedding if the response contains no embedding
+func FirstEmbedding(embeddingsResponse *openai.CreateEmbeddingResponse) ([]float64, error) {
+	if embeddingsResponse == nil || len(embeddingsResponse.Data) == 0 {
+		return nil, fmt.Errorf("%w: the embeddings response contains no data", ErrEmptyEmbedding)
+	}
+	return embeddingsResponse.Data[0].Embedding, nil
+}
+
+// EmbedConcurrent creates the embeddings of the texts with at most concurrency requests at a time.
+// The embeddings are returned in the same order as the texts.
+// If some texts fail, their embedding is nil and the returned error lists them by index.
+func EmbedConcurrent(ctx context.Context, client openai.Client, model string, texts []string, concurrency int, opts EmbedOptions) ([][]float64, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	embeddings := make([][]float64, len(texts))
+	errs := make([]error, len(texts))
+
+	logger := loggerOrDiscard(opts.Logger)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(texts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				embedding, err := Embed(ctx, client, model, texts[idx], opts)
+				embeddings[idx] = embedding
+				if err != nil {
+					errs[idx] = fmt.Errorf("text %d: %w", idx, err)
+					logger.Warn("failed to create the embedding", "text", idx, "error", err)
+				}
+			}
+		}()
+	}
+
+	for idx := range texts {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+
+	return embeddings, errors.Join(errs...)
+}
@@ -0,0 +1,19 @@
+package rag
+
+import "errors"
+
+// The errors returned by the vector store, test them with errors.Is
+var (
+	// ErrRecordNotFound is returned when no record of the store has the requested id
+	ErrRecordNotFound = errors.New("record not found")
+	// ErrDimensionMismatch is returned when the embedding length differs from the store dimension
+	// (ex: embeddings created by two different models)
+	ErrDimensionMismatch = errors.New("the embedding dimension does not match the store dimension")
+	// ErrEmptyEmbedding is returned when a record or a query has no embedding
+	ErrEmptyEmbedding = errors.New("the embedding is empty")
+	// ErrEmptyStore is returned by the searches when the store has no record
+	ErrEmptyStore = errors.New("the vector store is empty")
+	// ErrInvalidThreshold is returned by the searches when the limit is not a cosine similarity in [-1, 1]
+	// (ex: 60 instead of 0.6)
+	ErrInvalidThreshold = errors.New("the similarity threshold must be between -1 and 1")
+)
@@ -0,0 +1,120 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go"
+)
+
+// IngestOptions are the settings of the ingestion helpers (the zero value uses the defaults)
+type IngestOptions struct {
+	// MaxChunkLength is the maximum number of characters (runes) of a chunk given to the embeddings model
+	// (0 means no limit): the longer chunks are truncated or rejected by the model
+	MaxChunkLength int
+	// SkipOversizedChunks skips the chunks longer than MaxChunkLength instead of splitting them with ChunkText
+	SkipOversizedChunks bool
+	// Embed are the options of the embeddings requests (ex: their timeout and the DocumentPrefix)
+	Embed EmbedOptions
+	// Logger receives the progress and the failures of the ingestion (nil discards them)
+	Logger *slog.Logger
+}
+
+// IngestChunks creates the embeddings of the chunks (in one batch) and saves them in the store.
+// All the chunks are processed even if some of them fail:
+// the returned error lists every chunk that could not be embedded or saved.
+func IngestChunks(ctx context.Context, client openai.Client, model string, store VectorStore, chunks []string, opts IngestOptions) error {
+	return IngestChunksWithMetadata(ctx, client, model, store, chunks, nil, opts)
+}
+
+// IngestChunksWithMetadata works like IngestChunks and attaches a copy of the metadata
+// (ex: the path of the source file) to every record
+func IngestChunksWithMetadata(ctx context.Context, client openai.Client, model string, store VectorStore, chunks []string, metadata map[string]string, opts IngestOptions) error {
+	logger := loggerOrDiscard(opts.Logger)
+	chunks = limitChunkLengths(chunks, opts)
+
+	embeddings, err := EmbedDocuments(ctx, client, model, chunks, opts.Embed)
+	if err != nil {
+		// fall back to one request per chunk to find the failing chunks
+		logger.Warn("batch embedding failed, falling back to one request per chunk", "error", err)
+		return ingestChunksOneByOne(ctx, client, model, store, chunks, metadata, opts)
+	}
+
+	var errs []error
+	for idx, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		_, err = store.SaveContext(ctx, VectorRecord{
+			Prompt:    chunk,
+			Embedding: embeddings[idx],
+			Metadata:  maps.Clone(metadata),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chunk %d: failed to save the record: %w", idx, err))
+		}
+	}
+	logger.Info("chunks ingested", "total", len(chunks), "failed", len(errs))
+	return errors.Join(errs...)
+}
+
+// ingestChunksOneByOne creates and saves the embeddings of the chunks one request at a time
+func ingestChunksOneByOne(ctx context.Context, client openai.Client, model string, store VectorStore, chunks []string, metadata map[string]string, opts IngestOptions) error {
+	logger := loggerOrDiscard(opts.Logger)
+	var errs []error
+
+	for idx, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		embedding, err := Embed(ctx, client, model, opts.Embed.DocumentPrefix+chunk, opts.Embed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chunk %d: %w", idx, err))
+			logger.Warn("failed to create the embedding", "chunk", idx, "error", err)
+			continue
+		}
+
+		_, err = store.SaveContext(ctx, VectorRecord{
+			Prompt:    chunk,
+			Embedding: embedding,
+			Metadata:  maps.Clone(metadata),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chunk %d: failed to save the record: %w", idx, err))
+		}
+	}
+	logger.Info("chunks ingested", "total", len(chunks), "failed", len(errs))
+	return errors.Join(errs...)
+}
+
+// limitChunkLengths splits (or skips, see SkipOversizedChunks) the chunks longer than opts.MaxChunkLength characters
+func limitChunkLengths(chunks []string, opts IngestOptions) []string {
+	maxLength := opts.MaxChunkLength
+	if maxLength <= 0 {
+		return chunks
+	}
+	logger := loggerOrDiscard(opts.Logger)
+
+	limited := make([]string, 0, len(chunks))
+	for idx, chunk := range chunks {
+		length := utf8.RuneCountInString(chunk)
+		switch {
+		case length <= maxLength:
+			limited = append(limited, chunk)
+		case opts.SkipOversizedChunks:
+			logger.Warn("chunk too long, skipped", "chunk", idx, "length", length, "max", maxLength)
+		default:
+			logger.Warn("chunk too long, split", "chunk", idx, "length", length, "max", maxLength)
+			limited = append(limited, ChunkText(chunk, maxLength, 0)...)
+		}
+	}
+	return limited
+}
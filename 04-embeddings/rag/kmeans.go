@@ -0,0 +1,104 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// KMeans groups the records in k clusters of similar embeddings (cosine distance)
+// and returns the record ids of every cluster.
+// The initial centroids are picked deterministically (farthest-point), so the same records give the same clusters.
+func KMeans(records []VectorRecord, k int, iterations int) ([][]string, error) {
+	if k <= 0 {
+		return nil, errors.New("k must be positive")
+	}
+	if k > len(records) {
+		return nil, fmt.Errorf("k (%d) is greater than the number of records (%d)", k, len(records))
+	}
+
+	// Sort the records by id to not depend on the order of the store map
+	records = slices.Clone(records)
+	slices.SortFunc(records, func(a, b VectorRecord) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	centroids := initialCentroids(records, k)
+	assignments := make([]int, len(records))
+
+	for range max(iterations, 1) {
+		changed := false
+		for i, record := range records {
+			nearest := nearestCentroid(record.Embedding, centroids)
+			if nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+
+		// Move every centroid to the mean of its records
+		for c := range centroids {
+			var members [][]float64
+			for i, record := range records {
+				if assignments[i] == c {
+					members = append(members, record.Embedding)
+				}
+			}
+			if len(members) > 0 {
+				centroids[c] = meanVector(members)
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	clusters := make([][]string, k)
+	for i, record := range records {
+		clusters[assignments[i]] = append(clusters[assignments[i]], record.Id)
+	}
+	return clusters, nil
+}
+
+// initialCentroids picks the first record, then repeatedly the record farthest from the centroids already picked
+func initialCentroids(records []VectorRecord, k int) [][]float64 {
+	centroids := [][]float64{records[0].Embedding}
+	for len(centroids) < k {
+		farthest, farthestDistance := 0, -1.0
+		for i, record := range records {
+			distance := 1 - CosineSimilarity(record.Embedding, centroids[nearestCentroid(record.Embedding, centroids)])
+			if distance > farthestDistance {
+				farthest, farthestDistance = i, distance
+			}
+		}
+		centroids = append(centroids, records[farthest].Embedding)
+	}
+	return centroids
+}
+
+// nearestCentroid returns the index of the centroid with the highest cosine similarity to the embedding
+func nearestCentroid(embedding []float64, centroids [][]float64) int {
+	nearest, bestSimilarity := 0, CosineSimilarity(embedding, centroids[0])
+	for c := 1; c < len(centroids); c++ {
+		if similarity := CosineSimilarity(embedding, centroids[c]); similarity > bestSimilarity {
+			nearest, bestSimilarity = c, similarity
+		}
+	}
+	return nearest
+}
+
+// meanVector returns the component-wise mean of the vectors
+func meanVector(vectors [][]float64) []float64 {
+	mean := make([]float64, len(vectors[0]))
+	for _, vector := range vectors {
+		for i := range min(len(mean), len(vector)) {
+			mean[i] += vector[i]
+		}
+	}
+	for i := range mean {
+		mean[i] /= float64(len(vectors))
+	}
+	return mean
+}
@@ -0,0 +1,11 @@
+package rag
+
+import "log/slog"
+
+// loggerOrDiscard returns the logger of the options, or a logger discarding everything if it is nil
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return logger
+}
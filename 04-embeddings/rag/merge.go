@@ -0,0 +1,33 @@
+package rag
+
+import "fmt"
+
+// Merge copies the records of the other store into the store (ex: stores ingested in parallel, one per source).
+// A record whose id is already in the store is skipped, or replaces the existing one with MergeOverwrite.
+// Nothing is copied if a record of the other store has no embedding or if one of its embeddings
+// (Embedding or the multi-vector Embeddings) does not have the dimension of the store.
+func (mvs *MemoryVectorStore) Merge(other VectorStore) error {
+	records, err := other.GetAll()
+	if err != nil {
+		return err
+	}
+
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	dimension := mvs.dimension
+	for _, record := range records {
+		if dimension, err = checkEmbeddings(record, dimension); err != nil {
+			return fmt.Errorf("record %s: %w", record.Id, err)
+		}
+	}
+
+	for _, record := range records {
+		if _, exists := mvs.records[record.Id]; exists && !mvs.MergeOverwrite {
+			continue
+		}
+		if _, err := mvs.save(record); err != nil {
+			return fmt.Errorf("record %s: %w", record.Id, err)
+		}
+	}
+	return nil
+}
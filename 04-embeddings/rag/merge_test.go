@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		overwrite  bool
+		other      []VectorRecord
+		wantErr    error
+		wantPrompt map[string]string
+	}{
+		{
+			name:       "new ids",
+			other:      []VectorRecord{{Id: "b", Prompt: "new b", Embedding: []float64{0, 1}}},
+			wantPrompt: map[string]string{"a": "old a", "b": "new b"},
+		},
+		{
+			name:       "id collision skipped",
+			other:      []VectorRecord{{Id: "a", Prompt: "new a", Embedding: []float64{0, 1}}, {Id: "b", Prompt: "new b", Embedding: []float64{0, 1}}},
+			wantPrompt: map[string]string{"a": "old a", "b": "new b"},
+		},
+		{
+			name:       "id collision overwritten",
+			overwrite:  true,
+			other:      []VectorRecord{{Id: "a", Prompt: "new a", Embedding: []float64{0, 1}}, {Id: "b", Prompt: "new b", Embedding: []float64{0, 1}}},
+			wantPrompt: map[string]string{"a": "new a", "b": "new b"},
+		},
+		{
+			name:       "dimension mismatch",
+			other:      []VectorRecord{{Id: "b", Prompt: "new b", Embedding: []float64{0, 1}}, {Id: "c", Prompt: "new c", Embedding: []float64{0, 1, 0}}},
+			wantErr:    ErrDimensionMismatch,
+			wantPrompt: map[string]string{"a": "old a"},
+		},
+		{
+			name:       "dimension mismatch of a multi-vector embedding",
+			other:      []VectorRecord{{Id: "b", Prompt: "new b", Embedding: []float64{0, 1}, Embeddings: [][]float64{{1}}}},
+			wantErr:    ErrDimensionMismatch,
+			wantPrompt: map[string]string{"a": "old a"},
+		},
+		{
+			name:       "empty embedding",
+			other:      []VectorRecord{{Id: "b", Prompt: "new b"}},
+			wantErr:    ErrEmptyEmbedding,
+			wantPrompt: map[string]string{"a": "old a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &MemoryVectorStore{MergeOverwrite: test.overwrite}
+			if _, err := store.Save(VectorRecord{Id: "a", Prompt: "old a", Embedding: []float64{1, 0}}); err != nil {
+				t.Fatal(err)
+			}
+			// the other store has no dimension check: the records are put in directly
+			other := &MemoryVectorStore{records: map[string]VectorRecord{}}
+			for _, record := range test.other {
+				other.records[record.Id] = record
+			}
+
+			if err := store.Merge(other); !errors.Is(err, test.wantErr) {
+				t.Fatalf("Merge() = %v, want %v", err, test.wantErr)
+			}
+			// nothing is copied when the merge fails
+			if store.Len() != len(test.wantPrompt) {
+				t.Errorf("%d records, want %d", store.Len(), len(test.wantPrompt))
+			}
+			for id, prompt := range test.wantPrompt {
+				if record, ok := store.Get(id); !ok || record.Prompt != prompt {
+					t.Errorf("record %s = %+v, want the prompt %q", id, record, prompt)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxScore aggregates the similarities of a record with several queries by keeping the best one (0 without scores)
+func MaxScore(scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	best := scores[0]
+	for _, score := range scores[1:] {
+		best = max(best, score)
+	}
+	return best
+}
+
+// MeanScore aggregates the similarities of a record with several queries by averaging them (0 without scores)
+func MeanScore(scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, score := range scores {
+		sum += score
+	}
+	return sum / float64(len(scores))
+}
+
+// SearchMultiQuery returns the n records most similar to several queries (ex: paraphrases of a question):
+// every record is scored against every query embedding and the scores are combined by aggregate
+// (MaxScore if nil, or MeanScore). The aggregated score is set as the CosineSimilarity of the records.
+func (mvs *MemoryVectorStore) SearchMultiQuery(queries []VectorRecord, n int, aggregate func([]float64) float64) ([]VectorRecord, error) {
+	if len(queries) == 0 {
+		return nil, errors.New("at least one query is required")
+	}
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	for i, query := range queries {
+		if err := mvs.checkSearch(query, -1.0); err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+	}
+	if aggregate == nil {
+		aggregate = MaxScore
+	}
+
+	embeddings := make([][]float64, len(queries))
+	for i, query := range queries {
+		embeddings[i] = mvs.queryEmbedding(query.Embedding)
+	}
+
+	topN := newTopNCollector(n)
+	scores := make([]float64, len(embeddings))
+	for _, v := range mvs.records {
+		for i, embedding := range embeddings {
+			scores[i] = mvs.recordSimilarity(embedding, v)
+		}
+		v.CosineSimilarity = aggregate(scores)
+		topN.add(v)
+	}
+	return topN.sorted(), nil
+}
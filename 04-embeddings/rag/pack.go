@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// EstimateTokens returns a rough token count of the text (about 4 characters per token)
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// PackDocuments concatenates the prompts of the results, by decreasing score,
+// until the next one would exceed maxTokens (0 means no limit), so the context does not overflow.
+// Every prompt is wrapped in a <doc id="..." source="..."> element (see FormatDocument),
+// so the model does not merge the documents and can cite their ids, and prefixed with its score if includeScores.
+// countFn counts the tokens of a text; when it is nil, the TokenCount of the results stored by Save is used
+// (with an estimate of the <doc> element), or EstimateTokens if the count is missing.
+// It returns the packed documents and the results actually used.
+func PackDocuments(results []SearchResult, maxTokens int, includeScores bool, countFn func(string) int) (string, []SearchResult) {
+
+	sorted := slices.Clone(results)
+	slices.SortStableFunc(sorted, func(a, b SearchResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+
+	var documents strings.Builder
+	used := make([]SearchResult, 0, len(sorted))
+	tokens := 0
+	for _, result := range sorted {
+		document := FormatDocument(result, includeScores)
+		count := documentTokens(result, document, includeScores, countFn)
+		if maxTokens > 0 && tokens+count > maxTokens {
+			break
+		}
+		tokens += count
+		documents.WriteString(document)
+		used = append(used, result)
+	}
+	return documents.String(), used
+}
+
+// FormatDocument wraps the prompt of the result in a <doc> element with the id and the source of the record.
+// With includeScore, the prompt is prefixed with the score of the result.
+func FormatDocument(result SearchResult, includeScore bool) string {
+	attributes := fmt.Sprintf(`id="%s"`, html.EscapeString(result.Id))
+	if result.Source != "" {
+		attributes += fmt.Sprintf(` source="%s"`, html.EscapeString(result.Source))
+	}
+	prompt := strings.TrimSpace(result.Prompt)
+	if includeScore {
+		prompt = fmt.Sprintf("[relevance %.2f]\n%s", result.Score, prompt)
+	}
+	return fmt.Sprintf("<doc %s>\n%s\n</doc>\n", attributes, prompt)
+}
+
+// documentTokens returns the token count of the formatted document of the result
+func documentTokens(result SearchResult, document string, includeScores bool, countFn func(string) int) int {
+	if countFn != nil {
+		return countFn(document)
+	}
+	if result.TokenCount > 0 {
+		// the stored count of the prompt plus the <doc> element
+		return result.TokenCount + EstimateTokens(FormatDocument(SearchResult{Id: result.Id, Source: result.Source, Score: result.Score}, includeScores))
+	}
+	return EstimateTokens(document)
+}
@@ -0,0 +1,125 @@
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SaveToFile writes all the records of the store to a JSON file.
+// The file is replaced atomically, so a crash while saving keeps the previous file (see writeFileAtomic).
+func (mvs *MemoryVectorStore) SaveToFile(path string) error {
+	records, err := mvs.GetAll()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// writeFileAtomic writes the data to a temporary file of the directory of path, syncs it,
+// then renames it to path: the file has either its previous or its new content, never a truncated one
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath) // no-op once renamed
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+// LoadMemoryVectorStore creates a memory vector store from a JSON file written by SaveToFile.
+// The records are kept as saved, they must all have the same dimension (ErrDimensionMismatch).
+func LoadMemoryVectorStore(path string) (*MemoryVectorStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []VectorRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+
+	store := &MemoryVectorStore{
+		records: make(map[string]VectorRecord, len(records)),
+	}
+	for _, record := range records {
+		dimension, err := checkEmbeddings(record, store.dimension)
+		if err != nil {
+			return nil, fmt.Errorf("record %s: %w", record.Id, err)
+		}
+		store.dimension = dimension
+		store.records[record.Id] = record
+	}
+	return store, nil
+}
+
+// AppendToFile appends the record as a JSON line to a JSONL file (created if needed),
+// so a growing store is persisted without rewriting the whole file.
+func AppendToFile(path string, record VectorRecord) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(record); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadJSONL creates a memory vector store from a JSONL file written by AppendToFile (see LoadStream)
+func LoadJSONL(path string) (*MemoryVectorStore, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	store := NewMemoryVectorStore()
+	if err := store.LoadStream(file); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// LoadStream saves in the store the records read from NDJSON (JSONL, the format of AppendToFile),
+// decoding them one at a time so the memory does not depend on the size of the stream.
+// A record read later replaces the record with the same id.
+func (mvs *MemoryVectorStore) LoadStream(r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var record VectorRecord
+		if err := decoder.Decode(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("record %d: %w", line, err)
+		}
+		if _, err := mvs.Save(record); err != nil {
+			return fmt.Errorf("record %d: %w", line, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoadMemoryVectorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := randomStore(t, 100, 8).SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := LoadMemoryVectorStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the searches of a loaded store only read it (go test -race)
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.SearchTopNSimilarities(VectorRecord{Embedding: RandomVector(8, int64(-i))}, -1.0, 3); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if store.Len() != 100 || store.Dimension() != 8 {
+		t.Fatalf("%d records of dimension %d, want 100 of dimension 8", store.Len(), store.Dimension())
+	}
+}
+
+func TestSaveToFileSearchFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := randomStore(t, 3, 8).SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fields set by the searches are not persisted
+	for _, field := range []string{`"Weight"`, `"Passed"`} {
+		if strings.Contains(string(data), field) {
+			t.Errorf("the file contains %s:\n%s", field, data)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+	for _, content := range []string{"first", "second"} {
+		if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("content = %q, want %q", data, content)
+		}
+	}
+
+	// the temporary files are renamed or removed
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the directory, want only the checkpoint", len(entries))
+	}
+
+	// the temporary file is created next to the file, in an existing directory
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "checkpoint.json"), []byte("third"), 0644); err == nil {
+		t.Error("no error for a missing directory")
+	}
+}
@@ -0,0 +1,46 @@
+package rag
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+)
+
+// QueryLogger is implemented by the stores keeping the queries for analytics (ex: clustering the common questions),
+// Query logs the questions with QueryOptions.LogQueries
+type QueryLogger interface {
+	LogQuery(record VectorRecord) error
+}
+
+// LogQuery keeps the query record (question and embedding) apart from the documents,
+// with its time in the "timestamp" metadata (RFC 3339). It is safe for concurrent use.
+func (mvs *MemoryVectorStore) LogQuery(record VectorRecord) error {
+	record.Metadata = maps.Clone(record.Metadata)
+	if record.Metadata == nil {
+		record.Metadata = map[string]string{}
+	}
+	record.Metadata["timestamp"] = time.Now().UTC().Format(time.RFC3339)
+
+	mvs.queriesMutex.Lock()
+	defer mvs.queriesMutex.Unlock()
+	mvs.queries = append(mvs.queries, record)
+	return nil
+}
+
+// Queries returns the query records logged by LogQuery, in the logging order
+func (mvs *MemoryVectorStore) Queries() []VectorRecord {
+	mvs.queriesMutex.Lock()
+	defer mvs.queriesMutex.Unlock()
+	return slices.Clone(mvs.queries)
+}
+
+// SaveQueriesToFile writes the query records logged by LogQuery to a JSON file
+// (the same format as SaveToFile, so the queries can be loaded with LoadMemoryVectorStore)
+func (mvs *MemoryVectorStore) SaveQueriesToFile(path string) error {
+	data, err := json.MarshalIndent(mvs.Queries(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,108 @@
+package rag
+
+import (
+	"context"
+	"embeddings-demo/llm"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/openai/openai-go"
+)
+
+// ErrEmbeddingFailed is returned by Query when the embedding of the question failed after a retry,
+// so the caller can choose a fallback
+var ErrEmbeddingFailed = errors.New("the embedding of the question failed")
+
+// DefaultQueryInstructions is the system message introducing the documents to the chat model
+const DefaultQueryInstructions = `You are a useful AI agent. 
+Use only the following documents to answer:`
+
+// QueryOptions are the settings of Query (the zero value uses the defaults)
+type QueryOptions struct {
+	// Instructions is the system message introducing the documents (default: DefaultQueryInstructions)
+	Instructions string
+	// ContextTokens is the token budget of the documents added to the prompt (0 means no limit)
+	ContextTokens int
+	// IncludeScores prefixes every document with its score (ex: [relevance 0.82]),
+	// so the model can weigh the documents that barely passed the threshold
+	IncludeScores bool
+	// LogQueries makes the store log the question and its embedding when it is a QueryLogger
+	LogQueries bool
+	// Embed are the options of the embedding of the question (ex: its timeout and the QueryPrefix)
+	Embed EmbedOptions
+	// Logger receives the steps of the query (nil discards them)
+	Logger *slog.Logger
+}
+
+// Query answers the question with the documents of the store (Retrieval Augmented Generation):
+// it creates the embedding of the question, searches the topN most similar records above the threshold,
+// adds them to the prompt (within opts.ContextTokens), then streams the answer of the chat model to w.
+// It returns the whole answer and the sources (the records added to the prompt),
+// or ErrEmbeddingFailed if the embedding of the question failed twice.
+func Query(ctx context.Context, client openai.Client, embedModel, chatModel string, store VectorStore, question string, topN int, threshold float64, opts QueryOptions, w io.Writer) (string, []SearchResult, error) {
+	logger := loggerOrDiscard(opts.Logger)
+	embedding, err := embedQuestion(ctx, client, embedModel, question, opts.Embed, logger)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if queryLogger, ok := store.(QueryLogger); ok && opts.LogQueries {
+		if err := queryLogger.LogQuery(VectorRecord{Prompt: question, Embedding: embedding}); err != nil {
+			logger.Warn("failed to log the query", "error", err)
+		}
+	}
+
+	similarities, err := store.SearchTopNSimilarities(VectorRecord{
+		Embedding: embedding,
+	}, threshold, topN)
+	if errors.Is(err, ErrEmptyStore) {
+		// answer without documents
+		logger.Warn("the vector store is empty")
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	logger.Debug("similarities found", "count", len(similarities), "threshold", threshold)
+
+	// Keep the documents within the context budget
+	documents, sources := PackDocuments(SoftmaxWeights(NewSearchResults(similarities), 0.1), opts.ContextTokens, opts.IncludeScores, nil)
+	if len(sources) < len(similarities) {
+		logger.Debug("context budget reached", "max_tokens", opts.ContextTokens, "documents", len(sources), "skipped", len(similarities)-len(sources))
+	}
+	for _, source := range sources {
+		logger.Debug("document added to the context", "id", source.Id, "score", source.Score)
+	}
+	documentsContent := "Documents:\n" + documents + "\n"
+
+	instructions := opts.Instructions
+	if instructions == "" {
+		instructions = DefaultQueryInstructions
+	}
+	if opts.IncludeScores {
+		instructions += "\nEach document starts with its relevance to the question (from 0 to 1), rely more on the most relevant documents."
+	}
+
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage(instructions),
+		openai.SystemMessage(documentsContent),
+		openai.UserMessage(question),
+	}
+
+	answer, err := llm.Stream(ctx, client, chatModel, messages, llm.GenOptions{Preset: llm.Deterministic}, w)
+	return answer, sources, err
+}
+
+// embedQuestion creates the embedding of the question, retrying once on failure
+func embedQuestion(ctx context.Context, client openai.Client, embedModel, question string, opts EmbedOptions, logger *slog.Logger) ([]float64, error) {
+	embedding, err := EmbedQuery(ctx, client, embedModel, question, opts)
+	if err != nil && ctx.Err() == nil {
+		logger.Warn("embedding of the question failed, retrying", "error", err)
+		embedding, err = EmbedQuery(ctx, client, embedModel, question, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbeddingFailed, err)
+	}
+	return embedding, nil
+}
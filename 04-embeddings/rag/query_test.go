@@ -0,0 +1,104 @@
+package rag
+
+import (
+	"context"
+	"embeddings-demo/llm"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// keywordEmbedding is a fake embeddings model: the texts about cats and dogs get orthogonal embeddings
+func keywordEmbedding(input string) []float64 {
+	switch {
+	case strings.Contains(strings.ToLower(input), "cat"):
+		return []float64{1, 0}
+	case strings.Contains(strings.ToLower(input), "dog"):
+		return []float64{0, 1}
+	}
+	return []float64{1, 1}
+}
+
+// petStore returns a store with a record about cats and a record about dogs
+func petStore(t *testing.T) *MemoryVectorStore {
+	t.Helper()
+	store := NewMemoryVectorStore()
+	for _, prompt := range []string{"Cats purr.", "Dogs bark."} {
+		if _, err := store.Save(VectorRecord{Id: prompt, Prompt: prompt, Embedding: keywordEmbedding(prompt)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestQuery(t *testing.T) {
+	mock := &llm.MockTransport{
+		EmbeddingFunc: keywordEmbedding,
+		Completions:   []llm.MockCompletion{{Content: "A cat purrs."}},
+	}
+	opts := QueryOptions{Embed: EmbedOptions{QueryPrefix: "query: "}}
+
+	var w strings.Builder
+	answer, sources, err := Query(context.Background(), llm.NewMockClient(mock), "mock-embeddings", "mock-chat",
+		petStore(t), "What does a cat do?", 2, 0.5, opts, &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "A cat purrs." || w.String() != answer {
+		t.Errorf("answer = %q, streamed %q", answer, w.String())
+	}
+	if len(sources) != 1 || sources[0].Id != "Cats purr." {
+		t.Fatalf("sources = %v, want the record about cats", sources)
+	}
+
+	requests := mock.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want the embedding and the completion", len(requests))
+	}
+	var embeddingRequest struct {
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(requests[0].Body, &embeddingRequest); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(embeddingRequest.Input), "query: What does a cat do?") {
+		t.Errorf("embedded input = %s, want the question with the query prefix", embeddingRequest.Input)
+	}
+	// only the document above the threshold is given to the chat model
+	completionRequest := string(requests[1].Body)
+	if !strings.Contains(completionRequest, "Cats purr.") || strings.Contains(completionRequest, "Dogs bark.") {
+		t.Errorf("completion request = %s, want only the document about cats", completionRequest)
+	}
+}
+
+func TestQueryEmptyStore(t *testing.T) {
+	mock := &llm.MockTransport{
+		EmbeddingFunc: keywordEmbedding,
+		Completions:   []llm.MockCompletion{{Content: "I don't know."}},
+	}
+
+	answer, sources, err := Query(context.Background(), llm.NewMockClient(mock), "mock-embeddings", "mock-chat",
+		NewMemoryVectorStore(), "What does a cat do?", 2, 0.5, QueryOptions{}, &strings.Builder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "I don't know." || len(sources) != 0 {
+		t.Errorf("answer = %q, sources = %v, want an answer without documents", answer, sources)
+	}
+}
+
+func TestQueryEmbeddingFailed(t *testing.T) {
+	// no embeddings model: the embeddings requests fail
+	mock := &llm.MockTransport{}
+
+	_, _, err := Query(context.Background(), llm.NewMockClient(mock), "mock-embeddings", "mock-chat",
+		petStore(t), "What does a cat do?", 2, 0.5, QueryOptions{}, &strings.Builder{})
+	if !errors.Is(err, ErrEmbeddingFailed) {
+		t.Fatalf("error = %v, want ErrEmbeddingFailed", err)
+	}
+	// the embedding is retried once and the chat model is not called
+	if requests := mock.Requests(); len(requests) != 2 {
+		t.Errorf("%d requests, want 2 embeddings requests", len(requests))
+	}
+}
@@ -1,37 +1,344 @@
 package rag
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"iter"
+	"maps"
+	"math"
+	"slices"
 	"sort"
+	"sync"
 	"github.com/google/uuid"
 )
 
+// VectorRecord is a chunk of text (Prompt) and its embedding.
+// A record can carry additional embeddings in Embeddings (ex: the embedding of a summary of the chunk):
+// the searches score the record with the best matching of Embedding and Embeddings (multi-vector record).
 type VectorRecord struct {
-	Id               string    `json:"id"`
-	Prompt           string    `json:"prompt"`
-	Embedding        []float64 `json:"embedding"`
+	Id               string            `json:"id"`
+	Prompt           string            `json:"prompt"`
+	Embedding        []float64         `json:"embedding"`
+	RawEmbedding     []float64         `json:"raw_embedding,omitempty"`
+	Embeddings       [][]float64       `json:"embeddings,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	TokenCount       int               `json:"token_count,omitempty"`
 	CosineSimilarity float64
 }
 
+// VectorStore is the interface implemented by the vector stores
+type VectorStore interface {
+	GetAll() ([]VectorRecord, error)
+	Save(vectorRecord VectorRecord) (VectorRecord, error)
+	SaveContext(ctx context.Context, vectorRecord VectorRecord) (VectorRecord, error)
+	SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error)
+	SearchTopNSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error)
+}
+
+// SimilarityFunc computes a similarity score between two vectors (higher is more similar)
+type SimilarityFunc func(a, b []float64) float64
+
+// MemoryVectorStore is a vector store keeping the records in memory.
+// Create it with NewMemoryVectorStore (the zero value is ready to use too)
+// and access the records with Get, All and Len.
+// It is safe for concurrent use (ex: the searches of a server while a document is ingested):
+// the searches run in parallel and the writes are exclusive. Set the options before sharing the store.
 type MemoryVectorStore struct {
-	Records map[string]VectorRecord
+	// mutex guards records and dimension
+	mutex sync.RWMutex
+	// records are the records by id, only modified by Save and Delete so the invariants hold (ex: the dimension)
+	records map[string]VectorRecord
+	// RandomIds makes Save generate random ids instead of content-based ids
+	RandomIds bool
+	// SimilarityFunc overrides the cosine similarity used by the searches (optional)
+	SimilarityFunc SimilarityFunc
+	// Normalize makes Save store unit-length embeddings (the raw embedding is kept in the RawEmbedding of the record)
+	// and the searches use a dot product, cheaper than the cosine similarity and equivalent
+	Normalize bool
+	// MergeOverwrite makes Merge replace the records with the same id (they are skipped by default)
+	MergeOverwrite bool
+	// dimension is the length of the embeddings of the store, set by the first saved record
+	dimension int
+	// queries are the question records logged by LogQuery (analytics), apart from the documents
+	queries      []VectorRecord
+	queriesMutex sync.Mutex
+}
+
+// checkSearch checks a search before scanning the records: the query embedding must be set
+// and have the store dimension, the store must not be empty and the limit must be valid (see checkThreshold).
+// The caller holds the lock.
+func (mvs *MemoryVectorStore) checkSearch(embeddingFromQuestion VectorRecord, limit float64) error {
+	if len(embeddingFromQuestion.Embedding) == 0 {
+		return ErrEmptyEmbedding
+	}
+	if len(mvs.records) == 0 {
+		return ErrEmptyStore
+	}
+	if len(embeddingFromQuestion.Embedding) != mvs.dimension {
+		return fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(embeddingFromQuestion.Embedding), mvs.dimension)
+	}
+	return mvs.checkThreshold(limit)
+}
+
+// checkThreshold rejects the limits out of the cosine similarity range.
+// With a custom SimilarityFunc, the range is unknown and the limit is not checked.
+func (mvs *MemoryVectorStore) checkThreshold(limit float64) error {
+	if mvs.SimilarityFunc != nil {
+		return nil
+	}
+	return ValidateThreshold(limit)
+}
+
+// ValidateThreshold returns ErrInvalidThreshold if the limit is not a cosine similarity in [-1, 1]
+// (ex: to check a -threshold flag at startup)
+func ValidateThreshold(limit float64) error {
+	if math.IsNaN(limit) || limit < -1.0 || limit > 1.0 {
+		if limit > 1.0 && limit <= 100.0 {
+			return fmt.Errorf("%w: got %v (a percentage? use %v)", ErrInvalidThreshold, limit, limit/100.0)
+		}
+		return fmt.Errorf("%w: got %v", ErrInvalidThreshold, limit)
+	}
+	return nil
+}
+
+// similarity returns the similarity between two vectors with the store similarity function.
+// With Normalize, the vectors must have been normalized (see queryEmbedding).
+func (mvs *MemoryVectorStore) similarity(a, b []float64) float64 {
+	if mvs.SimilarityFunc != nil {
+		return mvs.SimilarityFunc(a, b)
+	}
+	if mvs.Normalize {
+		if len(a) != len(b) {
+			return 0.0
+		}
+		return dotProduct(a, b)
+	}
+	return CosineSimilarity(a, b)
+}
+
+// recordSimilarity returns the best similarity between the query and the embeddings of the record
+// (Embedding and the additional Embeddings)
+func (mvs *MemoryVectorStore) recordSimilarity(query []float64, record VectorRecord) float64 {
+	best := mvs.similarity(query, record.Embedding)
+	for _, embedding := range record.Embeddings {
+		best = max(best, mvs.similarity(query, embedding))
+	}
+	return best
+}
+
+// queryEmbedding returns the embedding to compare with the stored ones (normalized with Normalize)
+func (mvs *MemoryVectorStore) queryEmbedding(embedding []float64) []float64 {
+	if mvs.Normalize {
+		return Normalize(embedding)
+	}
+	return embedding
 }
 
 func (mvs *MemoryVectorStore) GetAll() ([]VectorRecord, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
 	var records []VectorRecord
-	for _, record := range mvs.Records {
+	for _, record := range mvs.records {
 		records = append(records, record)
 	}
 	return records, nil
 }
 
+// NewMemoryVectorStore creates an empty memory vector store
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{
+		records: make(map[string]VectorRecord),
+	}
+}
+
+// All yields the records of the store when All is called (in no particular order),
+// so the loop can modify the store
+func (mvs *MemoryVectorStore) All() iter.Seq[VectorRecord] {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	return slices.Values(slices.Collect(maps.Values(mvs.records)))
+}
+
+// Len returns the number of records of the store
+func (mvs *MemoryVectorStore) Len() int {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	return len(mvs.records)
+}
+
+// StoreStats are the statistics of a memory vector store
+type StoreStats struct {
+	Records   int `json:"records"`
+	Dimension int `json:"dimension"`
+	// Tokens is the sum of the token counts of the records (the size of the corpus)
+	Tokens int `json:"tokens"`
+}
+
+// Stats returns the number of records, the dimension and the total token count of the store
+func (mvs *MemoryVectorStore) Stats() StoreStats {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	stats := StoreStats{Records: len(mvs.records), Dimension: mvs.dimension}
+	for _, record := range mvs.records {
+		tokens := record.TokenCount
+		if tokens == 0 {
+			tokens = EstimateTokens(record.Prompt)
+		}
+		stats.Tokens += tokens
+	}
+	return stats
+}
+
+// Get returns the record with the given id and whether it is in the store
+func (mvs *MemoryVectorStore) Get(id string) (VectorRecord, bool) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	record, ok := mvs.records[id]
+	return record, ok
+}
+
+// Save saves the vector record in the store.
+// If the record has no id, the id is the hash of the prompt (see ContentHash),
+// so saving the same chunk twice does not create a duplicate. Set RandomIds to use random ids.
+// The estimated token count of the prompt is stored in TokenCount (see EstimateTokens).
+// The first record sets the dimension of the store: a record with an embedding of another length
+// is rejected with ErrDimensionMismatch.
 func (mvs *MemoryVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, error) {
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	return mvs.save(vectorRecord)
+}
+
+// save saves the vector record like Save, the caller holds the write lock
+func (mvs *MemoryVectorStore) save(vectorRecord VectorRecord) (VectorRecord, error) {
+	dimension, err := checkEmbeddings(vectorRecord, mvs.dimension)
+	if err != nil {
+		return VectorRecord{}, err
+	}
+	mvs.dimension = dimension
+
+	if mvs.Normalize && vectorRecord.RawEmbedding == nil {
+		vectorRecord.RawEmbedding = vectorRecord.Embedding
+		vectorRecord.Embedding = Normalize(vectorRecord.Embedding)
+		embeddings := make([][]float64, len(vectorRecord.Embeddings))
+		for i, embedding := range vectorRecord.Embeddings {
+			embeddings[i] = Normalize(embedding)
+		}
+		vectorRecord.Embeddings = embeddings
+	}
+
+	if vectorRecord.TokenCount == 0 {
+		vectorRecord.TokenCount = EstimateTokens(vectorRecord.Prompt)
+	}
+
 	if vectorRecord.Id == "" {
-		vectorRecord.Id = uuid.New().String()
+		if mvs.RandomIds {
+			vectorRecord.Id = uuid.New().String()
+		} else {
+			vectorRecord.Id = ContentHash(vectorRecord.Prompt)
+		}
 	}
-	mvs.Records[vectorRecord.Id] = vectorRecord
+	if mvs.records == nil {
+		mvs.records = make(map[string]VectorRecord)
+	}
+	mvs.records[vectorRecord.Id] = vectorRecord
 	return vectorRecord, nil
 }
 
+// checkEmbeddings checks that the record has an embedding and that all its embeddings (Embedding and Embeddings)
+// have the dimension (0 means the dimension of the record), and returns the dimension
+func checkEmbeddings(vectorRecord VectorRecord, dimension int) (int, error) {
+	if len(vectorRecord.Embedding) == 0 {
+		return 0, ErrEmptyEmbedding
+	}
+	if dimension == 0 {
+		dimension = len(vectorRecord.Embedding)
+	}
+	for _, embedding := range append([][]float64{vectorRecord.Embedding}, vectorRecord.Embeddings...) {
+		if len(embedding) != dimension {
+			return 0, fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(embedding), dimension)
+		}
+	}
+	return dimension, nil
+}
+
+// SaveContext works like Save but fails with the context error if ctx is done.
+// The memory store never blocks, the context matters for the backends doing I/O (ex: SQLite or remote).
+func (mvs *MemoryVectorStore) SaveContext(ctx context.Context, vectorRecord VectorRecord) (VectorRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return VectorRecord{}, err
+	}
+	return mvs.Save(vectorRecord)
+}
+
+// Update replaces the record having the same id, with the checks of Save.
+// With Normalize, the Embedding of the record is normalized again and becomes its RawEmbedding
+// (the RawEmbedding of a record read with Get is stale once its Embedding is changed).
+// It returns ErrRecordNotFound if no record has this id (use Save to add a record).
+func (mvs *MemoryVectorStore) Update(vectorRecord VectorRecord) (VectorRecord, error) {
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	if _, ok := mvs.records[vectorRecord.Id]; !ok {
+		return VectorRecord{}, fmt.Errorf("%w: %s", ErrRecordNotFound, vectorRecord.Id)
+	}
+	if mvs.Normalize {
+		vectorRecord.RawEmbedding = nil
+	}
+	return mvs.save(vectorRecord)
+}
+
+// Delete removes the record with the given id, or returns ErrRecordNotFound.
+// Once the store is empty, it accepts embeddings of any dimension again.
+func (mvs *MemoryVectorStore) Delete(id string) error {
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	if _, ok := mvs.records[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+	delete(mvs.records, id)
+	if len(mvs.records) == 0 {
+		mvs.dimension = 0
+	}
+	return nil
+}
+
+// Dimension returns the length of the embeddings of the store (0 if the store is empty)
+func (mvs *MemoryVectorStore) Dimension() int {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	return mvs.dimension
+}
+
+// ContentHash returns the SHA-256 hash (hex) of the text
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveDeduped saves the vector record only if no existing record is too similar to it.
+// The records are scored like the searches (SimilarityFunc, Normalize and the multi-vector Embeddings)
+// with the Embedding of the record as query.
+// If an existing record has a similarity greater than the threshold, the record is not saved,
+// the id of the existing record is returned and the boolean is false.
+// Otherwise it returns the id of the saved record and true.
+func (mvs *MemoryVectorStore) SaveDeduped(vectorRecord VectorRecord, threshold float64) (string, bool, error) {
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	query := mvs.queryEmbedding(vectorRecord.Embedding)
+	for id, record := range mvs.records {
+		if mvs.recordSimilarity(query, record) > threshold {
+			return id, false, nil
+		}
+	}
+	saved, err := mvs.save(vectorRecord)
+	if err != nil {
+		return "", false, err
+	}
+	return saved.Id, true, nil
+}
+
 // SearchSimilarities searches for vector records in the MemoryVectorStore that have a cosine distance similarity greater than or equal to the given limit.
 //
 // Parameters:
@@ -40,13 +347,20 @@ func (mvs *MemoryVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, err
 //
 // Returns:
 //   - []llm.VectorRecord: a slice of vector records that have a cosine distance similarity greater than or equal to the limit.
-//   - error: an error if any occurred during the search.
+//   - error: an error if any occurred during the search (ErrInvalidThreshold if the limit is not in [-1, 1],
+//     ErrEmptyStore, ErrEmptyEmbedding or ErrDimensionMismatch for the query).
 func (mvs *MemoryVectorStore) SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	if err := mvs.checkSearch(embeddingFromQuestion, limit); err != nil {
+		return nil, err
+	}
 
 	var records []VectorRecord
+	query := mvs.queryEmbedding(embeddingFromQuestion.Embedding)
 
-	for _, v := range mvs.Records {
-		distance := CosineSimilarity(embeddingFromQuestion.Embedding, v.Embedding)
+	for _, v := range mvs.records {
+		distance := mvs.recordSimilarity(query, v)
 		if distance >= limit {
 			v.CosineSimilarity = distance
 			records = append(records, v)
@@ -55,16 +369,111 @@ func (mvs *MemoryVectorStore) SearchSimilarities(embeddingFromQuestion VectorRec
 	return records, nil
 }
 
+// SearchSimilaritiesSeq yields the search results of the records that have a cosine similarity
+// greater than or equal to the threshold, as the store is scanned (unsorted).
+// The caller can stop the iteration early. The store is read-locked during the iteration,
+// so the loop must not call the methods of the store.
+// The query and the threshold are checked first, with the errors of SearchSimilarities.
+func (mvs *MemoryVectorStore) SearchSimilaritiesSeq(embeddingFromQuestion VectorRecord, threshold float64) (iter.Seq[SearchResult], error) {
+	mvs.mutex.RLock()
+	err := mvs.checkSearch(embeddingFromQuestion, threshold)
+	mvs.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(SearchResult) bool) {
+		mvs.mutex.RLock()
+		defer mvs.mutex.RUnlock()
+		query := mvs.queryEmbedding(embeddingFromQuestion.Embedding)
+		for _, v := range mvs.records {
+			similarity := mvs.recordSimilarity(query, v)
+			if similarity < threshold {
+				continue
+			}
+			if !yield(SearchResult{Id: v.Id, Score: similarity, Prompt: v.Prompt}) {
+				return
+			}
+		}
+	}, nil
+}
+
 // SearchTopNSimilarities searches for the top N similar vector records based on the given embedding from a question.
 // It returns a slice of vector records and an error if any.
 // The limit parameter specifies the minimum similarity score for a record to be considered similar.
 // The max parameter specifies the maximum number of vector records to return.
+// A limit out of [-1, 1] is rejected with ErrInvalidThreshold, an empty store with ErrEmptyStore.
+// Only the max best records are kept during the scan (bounded heap), so the memory is O(max).
 func (mvs *MemoryVectorStore) SearchTopNSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error) {
-	records, err := mvs.SearchSimilarities(embeddingFromQuestion, limit)
-	if err != nil {
+	return mvs.SearchTopNSimilaritiesBoosted(embeddingFromQuestion, limit, max, nil)
+}
+
+// SearchTopNSimilaritiesBoosted works like SearchTopNSimilarities but the similarity of every record
+// above the limit is multiplied by boost(record) before ranking (ex: to prefer authoritative sources).
+// A nil boost leaves the similarities unchanged.
+func (mvs *MemoryVectorStore) SearchTopNSimilaritiesBoosted(embeddingFromQuestion VectorRecord, limit float64, max int, boost func(VectorRecord) float64) ([]VectorRecord, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	if err := mvs.checkSearch(embeddingFromQuestion, limit); err != nil {
 		return nil, err
 	}
-	return getTopNVectorRecords(records, max), nil
+	return mvs.searchTopN(embeddingFromQuestion, limit, max, boost), nil
+}
+
+// searchTopN returns the max most similar records above the limit (not checked), the caller holds the lock
+func (mvs *MemoryVectorStore) searchTopN(embeddingFromQuestion VectorRecord, limit float64, max int, boost func(VectorRecord) float64) []VectorRecord {
+	topN := newTopNCollector(max)
+	query := mvs.queryEmbedding(embeddingFromQuestion.Embedding)
+
+	for _, v := range mvs.records {
+		similarity := mvs.recordSimilarity(query, v)
+		if similarity < limit {
+			continue
+		}
+		v.CosineSimilarity = similarity
+		if boost != nil {
+			v.CosineSimilarity *= boost(v)
+		}
+		topN.add(v)
+	}
+	return topN.sorted()
+}
+
+// SearchTopNSimilaritiesWithMisses returns the max most similar records whatever the limit,
+// with Passed set for the results whose score is greater than or equal to the limit.
+// When no record passes, it shows how close the best match was (ex: 0.58 for a limit of 0.6).
+func (mvs *MemoryVectorStore) SearchTopNSimilaritiesWithMisses(embeddingFromQuestion VectorRecord, limit float64, max int) ([]SearchResult, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	if err := mvs.checkSearch(embeddingFromQuestion, limit); err != nil {
+		return nil, err
+	}
+	results := NewSearchResults(mvs.searchTopN(embeddingFromQuestion, math.Inf(-1), max, nil))
+	for i := range results {
+		results[i].Passed = results[i].Score >= limit
+	}
+	return results, nil
+}
+
+// SearchSimilarToID returns the n records most similar to the stored record with the given id ("more like this"),
+// excluding the record itself. It returns ErrRecordNotFound if the id is not in the store.
+func (mvs *MemoryVectorStore) SearchSimilarToID(id string, n int) ([]SearchResult, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	reference, ok := mvs.records[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+
+	records := make([]VectorRecord, 0, len(mvs.records))
+	for _, v := range mvs.records {
+		if v.Id == id {
+			continue
+		}
+		v.CosineSimilarity = mvs.recordSimilarity(reference.Embedding, v)
+		records = append(records, v)
+	}
+	return NewSearchResults(getTopNVectorRecords(records, n)), nil
 }
 
 // getTopNVectorRecords returns the top N vector records based on their cosine similarity.
@@ -80,3 +489,28 @@ func getTopNVectorRecords(records []VectorRecord, max int) []VectorRecord {
 	}
 	return records[:max]
 }
+
+// SweepThreshold reports, for each threshold, how many records of the store
+// have a cosine similarity with the query greater than or equal to the threshold.
+// It helps to pick a sensible limit for SearchSimilarities.
+func SweepThreshold(query VectorRecord, store VectorStore, thresholds []float64) map[float64]int {
+	counts := make(map[float64]int, len(thresholds))
+	for _, threshold := range thresholds {
+		counts[threshold] = 0
+	}
+
+	records, err := store.GetAll()
+	if err != nil {
+		return counts
+	}
+
+	for _, record := range records {
+		similarity := CosineSimilarity(query.Embedding, record.Embedding)
+		for _, threshold := range thresholds {
+			if similarity >= threshold {
+				counts[threshold]++
+			}
+		}
+	}
+	return counts
+}
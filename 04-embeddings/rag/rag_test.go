@@ -0,0 +1,193 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// randomStore creates a memory vector store with size records of random embeddings
+// (seeded with the index of the record, so every run searches the same store)
+func randomStore(tb testing.TB, size, dimension int) *MemoryVectorStore {
+	tb.Helper()
+	store := NewMemoryVectorStore()
+	for i := range size {
+		if _, err := store.Save(VectorRecord{
+			Id:        strconv.Itoa(i),
+			Embedding: RandomVector(dimension, int64(i)),
+		}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestSearchSimilaritiesSeq(t *testing.T) {
+	store := randomStore(t, 100, 8)
+	query := VectorRecord{Embedding: RandomVector(8, -1)}
+
+	results, err := store.SearchSimilaritiesSeq(query, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := store.SearchSimilarities(query, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(slices.Collect(results)); got != len(want) {
+		t.Errorf("%d results, want %d", got, len(want))
+	}
+
+	for name, test := range map[string]struct {
+		store   *MemoryVectorStore
+		query   VectorRecord
+		wantErr error
+	}{
+		"empty query":        {store, VectorRecord{}, ErrEmptyEmbedding},
+		"dimension mismatch": {store, VectorRecord{Embedding: RandomVector(4, -1)}, ErrDimensionMismatch},
+		"empty store":        {NewMemoryVectorStore(), query, ErrEmptyStore},
+	} {
+		if _, err := test.store.SearchSimilaritiesSeq(test.query, 0.2); !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: error = %v, want %v", name, err, test.wantErr)
+		}
+	}
+	if _, err := store.SearchSimilaritiesSeq(query, 2); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("invalid threshold: error = %v, want ErrInvalidThreshold", err)
+	}
+}
+
+func TestSaveDeduped(t *testing.T) {
+	existing := VectorRecord{Id: "existing", Embedding: []float64{1, 0}, Embeddings: [][]float64{{0, 1}}}
+	tests := []struct {
+		name      string
+		store     *MemoryVectorStore
+		embedding []float64
+		wantSaved bool
+	}{
+		// cos(10°) = 0.985
+		{"duplicate", &MemoryVectorStore{}, []float64{0.985, 0.174}, false},
+		// cos(20°) = 0.94
+		{"near miss", &MemoryVectorStore{}, []float64{0.94, 0.342}, true},
+		{"duplicate of a multi-vector embedding", &MemoryVectorStore{}, []float64{0.174, 0.985}, false},
+		{"duplicate with Normalize", &MemoryVectorStore{Normalize: true}, []float64{9.85, 1.74}, false},
+		{"similarity function", &MemoryVectorStore{SimilarityFunc: func(a, b []float64) float64 { return 0 }}, []float64{1, 0}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.store.Save(existing); err != nil {
+				t.Fatal(err)
+			}
+
+			id, saved, err := test.store.SaveDeduped(VectorRecord{Id: "new", Embedding: test.embedding}, 0.95)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved != test.wantSaved {
+				t.Errorf("saved = %v, want %v", saved, test.wantSaved)
+			}
+			wantId, wantLen := "existing", 1
+			if test.wantSaved {
+				wantId, wantLen = "new", 2
+			}
+			if id != wantId || test.store.Len() != wantLen {
+				t.Errorf("id = %s with %d records, want %s with %d records", id, test.store.Len(), wantId, wantLen)
+			}
+		})
+	}
+}
+
+func TestUpdateNormalize(t *testing.T) {
+	store := &MemoryVectorStore{Normalize: true}
+	if _, err := store.Save(VectorRecord{Id: "a", Embedding: []float64{3, 4}}); err != nil {
+		t.Fatal(err)
+	}
+
+	record, _ := store.Get("a")
+	record.Embedding = []float64{0, 5}
+	if _, err := store.Update(record); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _ := store.Get("a")
+	if !slices.Equal(updated.Embedding, []float64{0, 1}) {
+		t.Errorf("Embedding = %v, want the new embedding normalized", updated.Embedding)
+	}
+	if !slices.Equal(updated.RawEmbedding, []float64{0, 5}) {
+		t.Errorf("RawEmbedding = %v, want the new embedding", updated.RawEmbedding)
+	}
+}
+
+func TestMemoryVectorStoreConcurrentUse(t *testing.T) {
+	const dimension = 8
+	store := randomStore(t, 100, dimension)
+	query := VectorRecord{Embedding: RandomVector(dimension, -1)}
+
+	// searches while records are saved and deleted (go test -race)
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				id := fmt.Sprintf("writer-%d-%d", i, j)
+				if _, err := store.Save(VectorRecord{Id: id, Embedding: RandomVector(dimension, int64(1000*i+j))}); err != nil {
+					t.Error(err)
+					return
+				}
+				if j%2 == 0 {
+					if err := store.Delete(id); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if _, err := store.SearchTopNSimilarities(query, -1.0, 3); err != nil {
+					t.Error(err)
+					return
+				}
+				results, err := store.SearchSimilaritiesSeq(query, 0.5)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for range results {
+				}
+				store.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := store.Len(), 100+4*25; got != want {
+		t.Errorf("%d records, want %d", got, want)
+	}
+}
+
+// go test ./rag -run '^$' -bench SearchTopNSimilarities
+func BenchmarkSearchTopNSimilarities(b *testing.B) {
+	for _, size := range []int{1_000, 10_000, 100_000} {
+		for _, dimension := range []int{384, 1024} {
+			b.Run(fmt.Sprintf("records=%d/dimension=%d", size, dimension), func(b *testing.B) {
+				if testing.Short() && size > 10_000 {
+					b.Skip("large store skipped in short mode")
+				}
+				store := randomStore(b, size, dimension)
+				query := VectorRecord{Embedding: RandomVector(dimension, -1)}
+
+				b.ReportAllocs()
+				for b.Loop() {
+					if _, err := store.SearchTopNSimilarities(query, -1.0, 5); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
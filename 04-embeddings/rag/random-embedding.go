@@ -0,0 +1,20 @@
+package rag
+
+import "math/rand/v2"
+
+// RandomVector returns a reproducible vector of dim random values in [-1, 1):
+// the same seed always gives the same vector (ex: for the examples and benchmarks without a model runner)
+func RandomVector(dim int, seed int64) []float64 {
+	random := rand.New(rand.NewPCG(uint64(seed), 0))
+	vector := make([]float64, dim)
+	for i := range vector {
+		vector[i] = random.Float64()*2 - 1
+	}
+	return vector
+}
+
+// RandomEmbedding returns a reproducible random unit vector of dim values (the normalized RandomVector),
+// so the cosine similarity of two random embeddings is their dot product
+func RandomEmbedding(dim int, seed int64) []float64 {
+	return Normalize(RandomVector(dim, seed))
+}
@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/openai/openai-go"
+)
+
+// reEmbedBatchSize is the number of prompts embedded by request during ReEmbed
+const reEmbedBatchSize = 32
+
+// ReEmbed migrates the store to a new embeddings model: the Prompt of every record is embedded again
+// with newModel (prefixed with opts.DocumentPrefix) and replaces its Embedding, the ids and metadata are kept.
+// The store is only changed once all the prompts are embedded, so a failure leaves it untouched.
+// The additional Embeddings of the records (multi-vector) can't be recreated from the prompt and are dropped.
+// progress (optional) is called after every batch with the number of records embedded so far.
+// The store is locked during the migration.
+func (mvs *MemoryVectorStore) ReEmbed(ctx context.Context, client openai.Client, newModel string, opts EmbedOptions, progress func(done, total int)) error {
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	logger := loggerOrDiscard(opts.Logger)
+	// sorted ids to embed the records in a stable order
+	ids := slices.Sorted(maps.Keys(mvs.records))
+
+	embeddings := make([][]float64, 0, len(ids))
+	for start := 0; start < len(ids); start += reEmbedBatchSize {
+		end := min(start+reEmbedBatchSize, len(ids))
+		prompts := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			prompts = append(prompts, mvs.records[id].Prompt)
+		}
+
+		batch, err := EmbedDocuments(ctx, client, newModel, prompts, opts)
+		if err != nil {
+			return fmt.Errorf("failed to re-embed the records %d to %d: %w", start, end-1, err)
+		}
+		embeddings = append(embeddings, batch...)
+		if progress != nil {
+			progress(end, len(ids))
+		}
+	}
+
+	previousRecords, previousDimension := mvs.records, mvs.dimension
+	mvs.records, mvs.dimension = make(map[string]VectorRecord, len(ids)), 0
+	for i, id := range ids {
+		record := previousRecords[id]
+		if len(record.Embeddings) > 0 {
+			logger.Warn("the additional embeddings of the record are dropped", "id", id, "count", len(record.Embeddings))
+		}
+		record.Embedding = embeddings[i]
+		record.RawEmbedding = nil
+		record.Embeddings = nil
+
+		if _, err := mvs.save(record); err != nil {
+			mvs.records, mvs.dimension = previousRecords, previousDimension
+			return fmt.Errorf("failed to save the record %s: %w", id, err)
+		}
+	}
+	logger.Info("store re-embedded", "model", newModel, "records", len(ids), "dimension", mvs.dimension)
+	return nil
+}
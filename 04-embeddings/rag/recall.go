@@ -0,0 +1,31 @@
+package rag
+
+// RecallAtK returns the fraction of the relevant ids (groundTruth[i] for queries[i]) found
+// in the top k records of the search of their query, over all the queries (micro-average).
+// It measures the impact of a retrieval change (ex: reranking on/off) on labeled queries.
+// A failed search counts as no relevant id found, the queries without ground truth (or the reverse) are ignored.
+func RecallAtK(store VectorStore, queries []VectorRecord, groundTruth [][]string, k int) float64 {
+	relevant, found := 0, 0
+	for i := range min(len(queries), len(groundTruth)) {
+		relevant += len(groundTruth[i])
+
+		records, err := store.SearchTopNSimilarities(queries[i], -1.0, k)
+		if err != nil {
+			continue
+		}
+		retrieved := make(map[string]bool, len(records))
+		for _, record := range records {
+			retrieved[record.Id] = true
+		}
+		for _, id := range groundTruth[i] {
+			if retrieved[id] {
+				found++
+			}
+		}
+	}
+
+	if relevant == 0 {
+		return 0
+	}
+	return float64(found) / float64(relevant)
+}
@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"context"
+	"embeddings-demo/llm"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/openai/openai-go"
+)
+
+// rerankSchema is the JSON schema of the relevance score returned by the reranking model
+var rerankSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"score": map[string]any{
+			"type": "number",
+		},
+	},
+	"required": []string{"score"},
+}
+
+// RerankWithModel asks the chat model to score the relevance (0 to 1) of every candidate to the query,
+// sets the Relevance of the candidates and returns them sorted by decreasing relevance
+func RerankWithModel(ctx context.Context, client openai.Client, model string, query string, candidates []SearchResult) ([]SearchResult, error) {
+	reranked := make([]SearchResult, len(candidates))
+	copy(reranked, candidates)
+
+	for i, candidate := range reranked {
+		messages := []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(`You are a relevance judge. 
+			Score from 0 (not relevant) to 1 (perfectly relevant) how well the document answers the question.`),
+			openai.UserMessage(fmt.Sprintf("Question: %s\n\nDocument:\n%s", query, candidate.Prompt)),
+		}
+
+		data, err := llm.GenerateStructuredWithFallback(ctx, client, model, messages, "relevance_score", rerankSchema, llm.GenOptions{Preset: llm.Deterministic})
+		if err != nil {
+			return nil, fmt.Errorf("failed to score %s: %w", candidate.Id, err)
+		}
+
+		var answer struct {
+			Score float64 `json:"score"`
+		}
+		if err := json.Unmarshal(data, &answer); err != nil {
+			return nil, fmt.Errorf("failed to score %s: %w", candidate.Id, err)
+		}
+		reranked[i].Relevance = min(max(answer.Score, 0.0), 1.0)
+	}
+
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Relevance > reranked[j].Relevance
+	})
+	return reranked, nil
+}
@@ -0,0 +1,75 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/openai/openai-go"
+)
+
+// checkpointEvery is the number of ingested chunks between two checkpoints
+const checkpointEvery = 10
+
+// ResumableIngest embeds and saves the chunks like IngestChunks, but the store is persisted
+// to checkpointPath every few chunks, when the context is cancelled and at the end
+// (atomically, so a crash while saving keeps the previous checkpoint).
+// The records are identified by the hash of their content (see ContentHash): when the ingestion is run again,
+// the store is reloaded from the checkpoint and the chunks already embedded are skipped.
+func ResumableIngest(ctx context.Context, client openai.Client, model string, store *MemoryVectorStore, chunks []string, checkpointPath string, opts IngestOptions) error {
+	logger := loggerOrDiscard(opts.Logger)
+	chunks = limitChunkLengths(chunks, opts)
+
+	checkpoint, err := LoadMemoryVectorStore(checkpointPath)
+	switch {
+	case err == nil:
+		for record := range checkpoint.All() {
+			if _, err := store.Save(record); err != nil {
+				return fmt.Errorf("failed to load the checkpoint: %w", err)
+			}
+		}
+		logger.Info("checkpoint loaded", "path", checkpointPath, "records", checkpoint.Len())
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("failed to load the checkpoint: %w", err)
+	}
+
+	var errs []error
+	ingested := 0
+	for idx, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		id := ContentHash(chunk)
+		if _, done := store.Get(id); done {
+			continue
+		}
+
+		embedding, err := Embed(ctx, client, model, opts.Embed.DocumentPrefix+chunk, opts.Embed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chunk %d: %w", idx, err))
+			continue
+		}
+
+		if _, err := store.SaveContext(ctx, VectorRecord{Id: id, Prompt: chunk, Embedding: embedding}); err != nil {
+			errs = append(errs, fmt.Errorf("chunk %d: failed to save the record: %w", idx, err))
+			continue
+		}
+
+		ingested++
+		if ingested%checkpointEvery == 0 {
+			if err := store.SaveToFile(checkpointPath); err != nil {
+				return errors.Join(append(errs, fmt.Errorf("failed to save the checkpoint: %w", err))...)
+			}
+			logger.Debug("checkpoint saved", "path", checkpointPath, "records", store.Len())
+		}
+	}
+
+	if err := store.SaveToFile(checkpointPath); err != nil {
+		errs = append(errs, fmt.Errorf("failed to save the checkpoint: %w", err))
+	}
+	logger.Info("chunks ingested", "total", len(chunks), "new", ingested, "failed", len(errs))
+	return errors.Join(errs...)
+}
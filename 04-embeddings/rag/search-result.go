@@ -0,0 +1,63 @@
+package rag
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// maxDumpedPromptLength is the number of characters of the prompt kept by DumpResults
+const maxDumpedPromptLength = 80
+
+// SearchResult is the stable JSON representation of a record returned by a similarity search
+type SearchResult struct {
+	Id    string  `json:"id"`
+	Score float64 `json:"score"`
+	// Weight is the softmax-normalized score set by SoftmaxWeights
+	Weight    float64 `json:"weight,omitempty"`
+	Relevance float64 `json:"relevance,omitempty"`
+	Prompt    string  `json:"prompt"`
+	// Source is the "path" metadata of the record (ex: the ingested file)
+	Source string `json:"source,omitempty"`
+	// TokenCount is the estimated token count of the prompt stored by Save
+	TokenCount int `json:"token_count,omitempty"`
+	// Passed is true when the score reached the limit of SearchTopNSimilaritiesWithMisses
+	Passed bool `json:"passed,omitempty"`
+}
+
+// NewSearchResults converts the vector records returned by a search into search results
+func NewSearchResults(records []VectorRecord) []SearchResult {
+	results := make([]SearchResult, 0, len(records))
+	for _, record := range records {
+		results = append(results, SearchResult{
+			Id:         record.Id,
+			Score:      record.CosineSimilarity,
+			Prompt:     record.Prompt,
+			Source:     record.Metadata["path"],
+			TokenCount: record.TokenCount,
+		})
+	}
+	return results
+}
+
+// DumpResults writes the search results as pretty JSON to w.
+// The prompts are truncated to keep the output readable.
+func DumpResults(w io.Writer, results []SearchResult) error {
+	dumped := make([]SearchResult, len(results))
+	for i, result := range results {
+		result.Prompt = truncate(result.Prompt, maxDumpedPromptLength)
+		dumped[i] = result
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dumped)
+}
+
+// truncate shortens text to max characters (runes) and adds an ellipsis when it was cut
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "…"
+}
@@ -0,0 +1,26 @@
+package rag
+
+import (
+	"context"
+
+	"github.com/openai/openai-go"
+)
+
+// SearchText creates the embedding of the query (see EmbedQuery) and returns the n most similar records
+// with a similarity greater than or equal to the threshold, sorted by decreasing score
+func (mvs *MemoryVectorStore) SearchText(ctx context.Context, client openai.Client, model string, query string, n int, threshold float64, opts EmbedOptions) ([]SearchResult, error) {
+	return searchText(ctx, client, model, mvs, query, n, threshold, opts)
+}
+
+// searchText embeds the query and searches the top n similar records of any vector store
+func searchText(ctx context.Context, client openai.Client, model string, store VectorStore, query string, n int, threshold float64, opts EmbedOptions) ([]SearchResult, error) {
+	embedding, err := EmbedQuery(ctx, client, model, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	similarities, err := store.SearchTopNSimilarities(VectorRecord{Embedding: embedding}, threshold, n)
+	if err != nil {
+		return nil, err
+	}
+	return NewSearchResults(similarities), nil
+}
@@ -0,0 +1,54 @@
+package rag
+
+import (
+	"context"
+	"embeddings-demo/llm"
+	"errors"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// SearchKnowledgeBaseTool returns the search_knowledge_base tool:
+// the model calls it with a query when it needs documents of the store to answer (agentic RAG).
+// The tool result is the content of the topN most similar records above the threshold.
+func SearchKnowledgeBaseTool(client openai.Client, embeddingsModel string, store VectorStore, topN int, threshold float64, opts EmbedOptions) llm.Tool {
+	return llm.Tool{
+		Definition: openai.ChatCompletionToolParam{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "search_knowledge_base",
+				Description: openai.String("Search the knowledge base for documents related to the query"),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]any{
+						"query": map[string]string{
+							"type":        "string",
+							"description": "what to search for",
+						},
+					},
+					"required": []string{"query"},
+				},
+			},
+		},
+		Handler: func(ctx context.Context, arguments map[string]any) (string, error) {
+			query, ok := arguments["query"].(string)
+			if !ok || query == "" {
+				return "", errors.New("the query argument is required")
+			}
+
+			results, err := searchText(ctx, client, embeddingsModel, store, query, topN, threshold, opts)
+			if err != nil && !errors.Is(err, ErrEmptyStore) {
+				return "", err
+			}
+			if len(results) == 0 {
+				return "No document found.", nil
+			}
+
+			documents := make([]string, 0, len(results))
+			for _, result := range results {
+				documents = append(documents, result.Prompt)
+			}
+			return "Documents:\n" + strings.Join(documents, "\n\n"), nil
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package rag
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// recordHeap is a min-heap of vector records ordered by cosine similarity:
+// the root is the least similar record kept
+type recordHeap []VectorRecord
+
+func (h recordHeap) Len() int           { return len(h) }
+func (h recordHeap) Less(i, j int) bool { return h[i].CosineSimilarity < h[j].CosineSimilarity }
+func (h recordHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *recordHeap) Push(x any)        { *h = append(*h, x.(VectorRecord)) }
+func (h *recordHeap) Pop() any {
+	old := *h
+	record := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return record
+}
+
+// topNCollector keeps the n most similar records seen, in O(n) memory
+type topNCollector struct {
+	n       int
+	records recordHeap
+}
+
+// newTopNCollector creates a collector of the n most similar records
+func newTopNCollector(n int) *topNCollector {
+	return &topNCollector{n: n, records: make(recordHeap, 0, max(n, 0))}
+}
+
+// add keeps the record if it is among the n most similar records seen so far
+func (c *topNCollector) add(record VectorRecord) {
+	if c.n <= 0 {
+		return
+	}
+	if len(c.records) < c.n {
+		heap.Push(&c.records, record)
+		return
+	}
+	if record.CosineSimilarity > c.records[0].CosineSimilarity {
+		c.records[0] = record
+		heap.Fix(&c.records, 0)
+	}
+}
+
+// sorted returns the records kept, from the most to the least similar
+func (c *topNCollector) sorted() []VectorRecord {
+	records := []VectorRecord(c.records)
+	slices.SortFunc(records, func(a, b VectorRecord) int {
+		switch {
+		case a.CosineSimilarity > b.CosineSimilarity:
+			return -1
+		case a.CosineSimilarity < b.CosineSimilarity:
+			return 1
+		}
+		return 0
+	})
+	return records
+}
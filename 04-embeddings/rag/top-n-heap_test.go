@@ -0,0 +1,67 @@
+package rag
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// randomRecords returns size records with random similarities
+func randomRecords(size int) []VectorRecord {
+	similarities := RandomVector(size, 42)
+	records := make([]VectorRecord, size)
+	for i := range records {
+		records[i] = VectorRecord{Id: fmt.Sprint(i), CosineSimilarity: similarities[i]}
+	}
+	return records
+}
+
+func TestTopNCollector(t *testing.T) {
+	records := randomRecords(1000)
+	for _, n := range []int{0, 1, 3, 10, 1000, 2000} {
+		t.Run(fmt.Sprintf("top %d", n), func(t *testing.T) {
+			topN := newTopNCollector(n)
+			for _, record := range records {
+				topN.add(record)
+			}
+			got := topN.sorted()
+			want := getTopNVectorRecords(slices.Clone(records), max(n, 0))
+
+			if len(got) != len(want) {
+				t.Fatalf("%d records, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i].Id != want[i].Id {
+					t.Fatalf("record %d = %s, want %s", i, got[i].Id, want[i].Id)
+				}
+			}
+		})
+	}
+}
+
+// go test ./rag -run '^$' -bench TopN
+func BenchmarkTopN(b *testing.B) {
+	const size, dimension, n = 100_000, 384, 3
+	store := randomStore(b, size, dimension)
+	query := VectorRecord{Embedding: RandomVector(dimension, -1)}
+
+	b.Run("heap", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := store.SearchTopNSimilarities(query, -1.0, n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("sort", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			records := make([]VectorRecord, 0, store.Len())
+			for record := range store.All() {
+				record.CosineSimilarity = CosineSimilarity(query.Embedding, record.Embedding)
+				records = append(records, record)
+			}
+			getTopNVectorRecords(records, n)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package rag
+
+import "slices"
+
+// QAExample is a labeled question: the embedding of the question
+// and the ids of the records expected to be retrieved for it
+type QAExample struct {
+	Question    string    `json:"question"`
+	Embedding   []float64 `json:"embedding"`
+	ExpectedIds []string  `json:"expected_ids"`
+}
+
+// tuneThresholdStep is the step of the thresholds swept by TuneThreshold
+const tuneThresholdStep = 0.01
+
+// TuneThreshold sweeps the thresholds from 0 to 1 and returns the one maximizing the F1 score
+// of SearchSimilarities on the labeled examples, with its precision and recall
+// (computed over all the examples). It replaces the hand-tuning of the search threshold.
+func TuneThreshold(store VectorStore, labeled []QAExample) (best float64, precision float64, recall float64) {
+	// Compute the similarities once, then count the retrieved records for every threshold
+	scores := make([][]VectorRecord, len(labeled))
+	expectedCount := 0
+	for i, example := range labeled {
+		// a failed search retrieves no record
+		records, _ := store.SearchSimilarities(VectorRecord{Embedding: example.Embedding}, -1.0)
+		scores[i] = records
+		expectedCount += len(example.ExpectedIds)
+	}
+	if expectedCount == 0 {
+		return 0, 0, 0
+	}
+
+	bestF1 := -1.0
+	for step := 0; step <= int(1/tuneThresholdStep); step++ {
+		threshold := float64(step) * tuneThresholdStep
+
+		retrieved, relevant := 0, 0
+		for i, example := range labeled {
+			for _, record := range scores[i] {
+				if record.CosineSimilarity < threshold {
+					continue
+				}
+				retrieved++
+				if slices.Contains(example.ExpectedIds, record.Id) {
+					relevant++
+				}
+			}
+		}
+
+		p, r := 0.0, float64(relevant)/float64(expectedCount)
+		if retrieved > 0 {
+			p = float64(relevant) / float64(retrieved)
+		}
+		f1 := 0.0
+		if p+r > 0 {
+			f1 = 2 * p * r / (p + r)
+		}
+		// keep the highest threshold on ties: fewer documents in the context
+		if f1 >= bestF1 {
+			best, precision, recall, bestF1 = threshold, p, r, f1
+		}
+	}
+	return best, precision, recall
+}
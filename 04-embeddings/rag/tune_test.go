@@ -0,0 +1,52 @@
+package rag
+
+import (
+	"math"
+	"testing"
+)
+
+// unitVector returns the 2D unit vector with the cosine x with {1, 0}
+func unitVector(x float64) []float64 {
+	return []float64{x, math.Sqrt(1 - x*x)}
+}
+
+func TestTuneThreshold(t *testing.T) {
+	store := NewMemoryVectorStore()
+	for _, record := range []VectorRecord{
+		{Id: "cats", Prompt: "Cats purr.", Embedding: unitVector(0.905)},
+		{Id: "dogs", Prompt: "Dogs bark.", Embedding: unitVector(0.5)},
+	} {
+		if _, err := store.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the question about cats scores 0.905 with cats and 0.5 with dogs,
+	// the question about dogs scores 0.866 with dogs and 0.425 with cats
+	labeled := []QAExample{
+		{Question: "What does a cat do?", Embedding: []float64{1, 0}, ExpectedIds: []string{"cats"}},
+		{Question: "What does a dog do?", Embedding: []float64{0, 1}, ExpectedIds: []string{"dogs"}},
+	}
+
+	tests := []struct {
+		name                                     string
+		labeled                                  []QAExample
+		wantThreshold, wantPrecision, wantRecall float64
+	}{
+		// every threshold in ]0.5, 0.866] retrieves only the expected records: the highest one is kept
+		{"labeled examples", labeled, 0.86, 1, 1},
+		// the dogs score 0.5 with the question about cats: above 0.5 the dogs are not retrieved anymore
+		{"two expected records", []QAExample{{Embedding: []float64{1, 0}, ExpectedIds: []string{"cats", "dogs"}}}, 0.5, 1, 1},
+		// retrieving the cats too is better than retrieving nothing
+		{"unexpected record scoring higher", []QAExample{{Embedding: []float64{1, 0}, ExpectedIds: []string{"dogs"}}}, 0.5, 0.5, 1},
+		{"no labeled example", nil, 0, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			threshold, precision, recall := TuneThreshold(store, test.labeled)
+			if math.Abs(threshold-test.wantThreshold) > 1e-9 || precision != test.wantPrecision || recall != test.wantRecall {
+				t.Errorf("TuneThreshold() = %v, %v, %v, want %v, %v, %v",
+					threshold, precision, recall, test.wantThreshold, test.wantPrecision, test.wantRecall)
+			}
+		})
+	}
+}
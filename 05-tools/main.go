@@ -4,18 +4,20 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
 
 // MODEL_RUNNER_BASE_URL=http://localhost:12434 go run main.go
+// MODEL_RUNNER_LLM_CHAT is optional (default: ai/llama3.2)
 func main() {
 	ctx := context.Background()
 
 	// Docker Model Runner base URL
-	chatURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
-	model := "ai/llama3.2"
+	chatURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
+	model := getEnv("MODEL_RUNNER_LLM_CHAT", "ai/llama3.2")
 
 	client := openai.NewClient(
 		option.WithBaseURL(chatURL),
@@ -96,3 +98,11 @@ func main() {
 		fmt.Println(toolCall.Function.Name, toolCall.Function.Arguments)
 	}
 }
+
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
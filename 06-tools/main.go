@@ -5,18 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
 
 // MODEL_RUNNER_BASE_URL=http://localhost:12434 go run main.go
+// MODEL_RUNNER_LLM_CHAT is optional (default: ai/llama3.2)
 func main() {
 	ctx := context.Background()
 
 	// Docker Model Runner base URL
-	chatURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
-	model := "ai/llama3.2"
+	chatURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
+	model := getEnv("MODEL_RUNNER_LLM_CHAT", "ai/llama3.2")
 
 	client := openai.NewClient(
 		option.WithBaseURL(chatURL),
@@ -138,3 +140,10 @@ func vulcanSalute(arguments map[string]interface{}) string {
 	}
 }
 
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
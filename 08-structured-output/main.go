@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -17,7 +18,7 @@ func main() {
 	ctx := context.Background()
 
 	// Docker Model Runner base URL
-	chatURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
+	chatURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
 	model := os.Getenv("MODEL_RUNNER_LLM_CHAT")
 
 	client := openai.NewClient(
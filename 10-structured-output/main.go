@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"unicode"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -17,7 +19,7 @@ func main() {
 	ctx := context.Background()
 
 	// Docker Model Runner base URL
-	chatURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
+	chatURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
 	model := os.Getenv("MODEL_RUNNER_LLM_CHAT")
 
 	client := openai.NewClient(
@@ -74,7 +76,8 @@ func main() {
 
 	var countriesList map[string][]string
 
-	err = json.Unmarshal([]byte(data), &countriesList)
+	// the models sometimes wrap the JSON in a ```json code block
+	err = json.Unmarshal([]byte(StripCodeFences(data)), &countriesList)
 
 	if err != nil {
 		panic(err)
@@ -129,8 +132,26 @@ func main() {
 		if err != nil {
 			panic(err)
 		}
-		fmt.Println("Response:", completion.Choices[0].Message.Content)
+		fmt.Println("Response:", StripCodeFences(completion.Choices[0].Message.Content))
 
 	}
 
 }
+
+// StripCodeFences removes the markdown code fences (``` or ```json) surrounding an answer,
+// small models often wrap the JSON they are asked for in them.
+// It is a copy of StripCodeFences of 04-embeddings/llm/code-fences.go (tested in code-fences_test.go): keep them in sync.
+func StripCodeFences(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "```") {
+		return s
+	}
+
+	body := strings.TrimPrefix(trimmed, "```")
+	// drop the language tag of the opening fence (ex: json), it ends with a space or a newline
+	if tagEnd := strings.IndexFunc(body, unicode.IsSpace); tagEnd >= 0 && !strings.ContainsAny(body[:tagEnd], "{[\"") {
+		body = body[tagEnd:]
+	}
+	body = strings.TrimSuffix(strings.TrimSpace(body), "```")
+	return strings.TrimSpace(body)
+}
@@ -4,45 +4,35 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
+	"time"
 
 	mcp_golang "github.com/metoro-io/mcp-golang"
+	mcp_http "github.com/metoro-io/mcp-golang/transport/http"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
 )
 
 func main() {
 	ctx := context.Background()
-	// Start the server process
-	cmd := exec.Command(
-		"docker",
-		"run",
-		"-i",
-		"--rm",
-		"alpine/socat",
-		"STDIO",
-		"TCP:host.docker.internal:8811",
-	)
 
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		log.Fatalf("😡 Failed to get stdin pipe: %v", err)
+	// MCP_INIT_TIMEOUT is optional (ex: 10s)
+	mcpInitTimeout := 30 * time.Second
+	if value := os.Getenv("MCP_INIT_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("😡 Invalid MCP_INIT_TIMEOUT: %v", err)
+		}
+		mcpInitTimeout = timeout
 	}
 
-	stdout, err := cmd.StdoutPipe()
+	// Create a new MCP client (MCP_TRANSPORT is optional: socat, docker (default) or http)
+	mcpClient, cmd, err := GetMCPClient(ctx, mcpInitTimeout)
 	if err != nil {
-		log.Fatalf("😡 Failed to get stdout pipe: %v", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		log.Fatalf("😡 Failed to start server: %v", err)
+		log.Fatalf("😡 Failed to create MCP client: %v", err)
 	}
-	defer cmd.Process.Kill()
-
-	clientTransport := stdio.NewStdioServerTransportWithIO(stdout, stdin)
-	mcpClient := mcp_golang.NewClient(clientTransport)
-
-	if _, err := mcpClient.Initialize(ctx); err != nil {
-		log.Fatalf("😡 Failed to initialize client: %v", err)
+	if cmd != nil {
+		defer cmd.Process.Kill()
 	}
 
 	// List available mcpTools
@@ -82,3 +72,139 @@ func main() {
 	}
 
 }
+
+// GetMCPClient connects to the MCP gateway with the transport selected by MCP_TRANSPORT and initializes the MCP client:
+//   - socat: bridge STDIO to the gateway with socat
+//   - docker: bridge STDIO to the gateway with socat in a container (alpine/socat)
+//   - http: HTTP transport to MCP_HTTP_BASE_URL (default http://host.docker.internal:8811) and MCP_HTTP_ENDPOINT (default /mcp)
+//   - unset: docker
+//
+// The command is nil with the http transport.
+// It fails if the bridge exits or if the MCP handshake does not complete within initTimeout.
+func GetMCPClient(ctx context.Context, initTimeout time.Duration) (*mcp_golang.Client, *exec.Cmd, error) {
+	transport := getEnv("MCP_TRANSPORT", "docker")
+
+	if transport == "http" {
+		clientTransport := mcp_http.NewHTTPClientTransport(getEnv("MCP_HTTP_ENDPOINT", "/mcp"))
+		clientTransport.WithBaseURL(getEnv("MCP_HTTP_BASE_URL", "http://host.docker.internal:8811"))
+
+		// Create a new MCP client
+		mcpClient := mcp_golang.NewClient(clientTransport)
+		if err := InitializeMCPClient(ctx, mcpClient, nil, initTimeout); err != nil {
+			return nil, nil, err
+		}
+		return mcpClient, nil, nil
+	}
+
+	// Start the MCP server process
+	cmd, err := MCPBridgeCommand(transport)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("😡 failed to get stdin pipe: %v", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("😡 failed to get stdout pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("😡 couldn't start %s: %v", cmd.Path, err)
+	}
+
+	// socat exits when it can't reach the MCP gateway
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	clientTransport := stdio.NewStdioServerTransportWithIO(stdout, stdin)
+
+	// Create a new MCP client
+	mcpClient := mcp_golang.NewClient(clientTransport)
+
+	if err := InitializeMCPClient(ctx, mcpClient, exited, initTimeout); err != nil {
+		cmd.Process.Kill()
+		return nil, nil, err
+	}
+	return mcpClient, cmd, nil
+}
+
+// InitializeMCPClient makes the MCP handshake.
+// It fails if the bridge exits (exited is nil without bridge) or if the handshake does not complete within initTimeout.
+func InitializeMCPClient(ctx context.Context, mcpClient *mcp_golang.Client, exited <-chan error, initTimeout time.Duration) error {
+	initialized := make(chan error, 1)
+	go func() {
+		_, err := mcpClient.Initialize(ctx)
+		initialized <- err
+	}()
+
+	select {
+	case err := <-initialized:
+		if err != nil {
+			return fmt.Errorf("😡 failed to initialize client: %v", err)
+		}
+		return nil
+	case err := <-exited:
+		return fmt.Errorf("😡 socat exited before the MCP handshake (is the MCP gateway listening on host.docker.internal:8811?): %v", err)
+	case <-time.After(initTimeout):
+		return fmt.Errorf("😡 MCP handshake timed out after %s (is the MCP gateway running?)", initTimeout)
+	case <-ctx.Done():
+		return fmt.Errorf("😡 MCP initialization canceled: %v", ctx.Err())
+	}
+}
+
+// MCPBridgeCommand returns the command bridging STDIO to the MCP gateway for the transport (socat or docker).
+// Without transport, it is socat if it is installed, otherwise socat in a container (alpine/socat) if docker is installed.
+// To run it in a container (with compose for example), the image needs to have socat or docker installed.
+func MCPBridgeCommand(transport string) (*exec.Cmd, error) {
+	socatCommand := exec.Command(
+		"socat",
+		"STDIO",
+		"TCP:host.docker.internal:8811",
+	)
+	dockerCommand := exec.Command(
+		"docker",
+		"run",
+		"-i",
+		"--rm",
+		"alpine/socat",
+		"STDIO",
+		"TCP:host.docker.internal:8811",
+	)
+
+	switch transport {
+	case "socat":
+		if _, err := exec.LookPath("socat"); err != nil {
+			return nil, fmt.Errorf("😡 socat not found in PATH: install it or use MCP_TRANSPORT=docker or MCP_TRANSPORT=http")
+		}
+		return socatCommand, nil
+	case "docker":
+		if _, err := exec.LookPath("docker"); err != nil {
+			return nil, fmt.Errorf("😡 docker not found in PATH: install it or use MCP_TRANSPORT=socat or MCP_TRANSPORT=http")
+		}
+		return dockerCommand, nil
+	case "":
+		if _, err := exec.LookPath("socat"); err == nil {
+			return socatCommand, nil
+		}
+		if _, err := exec.LookPath("docker"); err == nil {
+			return dockerCommand, nil
+		}
+		return nil, fmt.Errorf("😡 socat not found in PATH (and docker neither to run alpine/socat): install socat or docker, or use MCP_TRANSPORT=http")
+	default:
+		return nil, fmt.Errorf("😡 unknown MCP_TRANSPORT %q (socat, docker or http)", transport)
+	}
+}
+
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
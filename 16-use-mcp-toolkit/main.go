@@ -5,10 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
+	"time"
 
 	mcp_golang "github.com/metoro-io/mcp-golang"
+	mcp_http "github.com/metoro-io/mcp-golang/transport/http"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
 
 	"github.com/openai/openai-go"
@@ -22,7 +27,7 @@ func main() {
 	ctx := context.Background()
 
 	// Docker Model Runner base URL
-	chatURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
+	chatURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
 	modelTools := os.Getenv("MODEL_RUNNER_LLM_TOOLS")
 	modelChat := os.Getenv("MODEL_RUNNER_LLM_CHAT")
 
@@ -43,13 +48,24 @@ func main() {
 		The output format MUST be in markdown.
 	`
 
-	// Create a new MCP client
-	mcpClient, cmd, err := GetMCPClient(ctx)
+	// MCP_INIT_TIMEOUT is optional (ex: 10s)
+	mcpInitTimeout := 30 * time.Second
+	if value := os.Getenv("MCP_INIT_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("😡 Invalid MCP_INIT_TIMEOUT: %v", err)
+		}
+		mcpInitTimeout = timeout
+	}
 
+	// Create a new MCP client (MCP_TRANSPORT is optional: socat, docker or http)
+	mcpClient, cmd, err := GetMCPClient(ctx, mcpInitTimeout)
 	if err != nil {
 		log.Fatalf("😡 Failed to create MCP client: %v", err)
 	}
-	defer cmd.Process.Kill()
+	if cmd != nil {
+		defer cmd.Process.Kill()
+	}
 
 	// Get the list of the available MCP tools
 	mcpTools, err := mcpClient.ListTools(ctx, nil)
@@ -60,9 +76,12 @@ func main() {
 	fmt.Println("🛠️  Available Tools (MCP format): ", len(mcpTools.Tools))
 
 	filteredTools := []mcp_golang.ToolRetType{}
+	// the MCP tools by name, to validate the arguments of the tool calls
+	mcpToolsByName := map[string]mcp_golang.ToolRetType{}
 	for _, tool := range mcpTools.Tools {
 		if tool.Name == "brave_web_search" || tool.Name == "fetch" { //|| tool.Name == "fetch"
 			filteredTools = append(filteredTools, tool)
+			mcpToolsByName[tool.Name] = tool
 		}
 	}
 
@@ -70,6 +89,16 @@ func main() {
 	// Convert the mcp tools to openai tools
 	openAITools := ConvertToOpenAITools(filteredTools)
 
+	// TOOL_RESULT_MAX_CHARS is optional: the longer tool responses are truncated (ex: 8000)
+	toolResultMaxChars := 0
+	if value := os.Getenv("TOOL_RESULT_MAX_CHARS"); value != "" {
+		maxChars, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatalf("😡 Invalid TOOL_RESULT_MAX_CHARS: %v", err)
+		}
+		toolResultMaxChars = maxChars
+	}
+
 	// Create a list of messages for the chat completion request
 	messages := []openai.ChatCompletionMessageParamUnion{
 		openai.SystemMessage(systemInstructions),
@@ -113,18 +142,31 @@ func main() {
 			log.Println("😡 Failed to unmarshal arguments:", err)
 		}
 
+		// reject the tools invented by the model
+		tool, ok := mcpToolsByName[toolCall.Function.Name]
+		if !ok {
+			log.Println("😡 Unknown tool:", toolCall.Function.Name)
+			continue
+		}
+		if err := ValidateToolArgs(tool, args); err != nil {
+			log.Println("😡 Invalid tool arguments:", err)
+			continue
+		}
+
 		// Call the tool with the arguments
 		toolResponse, err := mcpClient.CallTool(ctx, toolCall.Function.Name, args)
 		if err != nil {
 			log.Println("😡 Failed to call tool:", err)
 		}
 		if toolResponse != nil && len(toolResponse.Content) > 0 && toolResponse.Content[0].TextContent != nil {
-			fmt.Println("📝 Tool response:", toolResponse.Content[0].TextContent.Text)
+			// Cut the long tool results (ex: web pages) to stay under the context limit
+			toolResult := TruncateToolResult(toolResponse.Content[0].TextContent.Text, toolResultMaxChars)
+			fmt.Println("📝 Tool response:", toolResult)
 
 			messages = append(
 				messages,
 				openai.ToolMessage(
-					toolResponse.Content[0].TextContent.Text,
+					toolResult,
 					toolCall.ID,
 				),
 			)
@@ -133,9 +175,10 @@ func main() {
 	}
 	fmt.Println("🎉 tools execution completed.")
 
-	// only for ai/qwen3:latest
-	//messages = append(messages, openai.SystemMessage("/no_think"))
-		
+	// THINKING=true lets the thinking models (ai/qwen3) think before answering
+	thinking, _ := strconv.ParseBool(os.Getenv("THINKING"))
+	messages = AddThinkingDirective(messages, modelChat, thinking)
+
 	params = openai.ChatCompletionNewParams{
 		Messages:    messages,
 		Model:       modelChat,
@@ -153,26 +196,54 @@ func main() {
 	}
 
 }
-func GetMCPClient(ctx context.Context) (*mcp_golang.Client, *exec.Cmd, error) {
-	/*
-		cmd := exec.Command(
-			"docker",
-			"run",
-			"-i",
-			"--rm",
-			"alpine/socat",
-			"STDIO",
-			"TCP:host.docker.internal:8811",
-		)
-	*/
-	// To run it in a container (with compose for example), the image needs to have docker installed
+
+// IsThinkingModel reports whether the model is a Qwen3 family model,
+// those models think (<think> block) before answering unless they get the /no_think directive
+func IsThinkingModel(model string) bool {
+	return strings.Contains(strings.ToLower(model), "qwen3")
+}
+
+// AddThinkingDirective appends the /think or /no_think system message for the thinking models
+// (see IsThinkingModel), the other models get the messages unchanged
+func AddThinkingDirective(messages []openai.ChatCompletionMessageParamUnion, model string, thinking bool) []openai.ChatCompletionMessageParamUnion {
+	if !IsThinkingModel(model) {
+		return messages
+	}
+	if thinking {
+		return append(messages, openai.SystemMessage("/think"))
+	}
+	return append(messages, openai.SystemMessage("/no_think"))
+}
+
+// GetMCPClient connects to the MCP gateway with the transport selected by MCP_TRANSPORT and initializes the MCP client:
+//   - socat: bridge STDIO to the gateway with socat
+//   - docker: bridge STDIO to the gateway with socat in a container (alpine/socat)
+//   - http: HTTP transport to MCP_HTTP_BASE_URL (default http://host.docker.internal:8811) and MCP_HTTP_ENDPOINT (default /mcp)
+//   - unset: socat if it is installed, otherwise docker
+//
+// The command is nil with the http transport.
+// It fails if the bridge exits or if the MCP handshake does not complete within initTimeout.
+func GetMCPClient(ctx context.Context, initTimeout time.Duration) (*mcp_golang.Client, *exec.Cmd, error) {
+	transport := os.Getenv("MCP_TRANSPORT")
+
+	if transport == "http" {
+		clientTransport := mcp_http.NewHTTPClientTransport(getEnv("MCP_HTTP_ENDPOINT", "/mcp"))
+		clientTransport.WithBaseURL(getEnv("MCP_HTTP_BASE_URL", "http://host.docker.internal:8811"))
+
+		// Create a new MCP client
+		mcpClient := mcp_golang.NewClient(clientTransport)
+		if err := InitializeMCPClient(ctx, mcpClient, nil, initTimeout); err != nil {
+			return nil, nil, err
+		}
+		return mcpClient, nil, nil
+	}
 
 	// Start the MCP server process
-	cmd := exec.Command(
-		"socat",
-		"STDIO",
-		"TCP:host.docker.internal:8811",
-	)
+	cmd, err := MCPBridgeCommand(transport)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, nil, fmt.Errorf("😡 failed to get stdin pipe: %v", err)
@@ -184,21 +255,184 @@ func GetMCPClient(ctx context.Context) (*mcp_golang.Client, *exec.Cmd, error) {
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, nil, fmt.Errorf("😡 failed to start server: %v", err)
+		return nil, nil, fmt.Errorf("😡 couldn't start %s: %v", cmd.Path, err)
 	}
 
+	// socat exits when it can't reach the MCP gateway
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
 	clientTransport := stdio.NewStdioServerTransportWithIO(stdout, stdin)
 
 	// Create a new MCP client
 	mcpClient := mcp_golang.NewClient(clientTransport)
 
-	if _, err := mcpClient.Initialize(ctx); err != nil {
-		return nil, nil, fmt.Errorf("😡 failed to initialize client: %v", err)
+	if err := InitializeMCPClient(ctx, mcpClient, exited, initTimeout); err != nil {
+		cmd.Process.Kill()
+		return nil, nil, err
 	}
-
 	return mcpClient, cmd, nil
 }
 
+// InitializeMCPClient makes the MCP handshake.
+// It fails if the bridge exits (exited is nil without bridge) or if the handshake does not complete within initTimeout.
+func InitializeMCPClient(ctx context.Context, mcpClient *mcp_golang.Client, exited <-chan error, initTimeout time.Duration) error {
+	initialized := make(chan error, 1)
+	go func() {
+		_, err := mcpClient.Initialize(ctx)
+		initialized <- err
+	}()
+
+	select {
+	case err := <-initialized:
+		if err != nil {
+			return fmt.Errorf("😡 failed to initialize client: %v", err)
+		}
+		return nil
+	case err := <-exited:
+		return fmt.Errorf("😡 socat exited before the MCP handshake (is the MCP gateway listening on host.docker.internal:8811?): %v", err)
+	case <-time.After(initTimeout):
+		return fmt.Errorf("😡 MCP handshake timed out after %s (is the MCP gateway running?)", initTimeout)
+	case <-ctx.Done():
+		return fmt.Errorf("😡 MCP initialization canceled: %v", ctx.Err())
+	}
+}
+
+// MCPBridgeCommand returns the command bridging STDIO to the MCP gateway for the transport (socat or docker).
+// Without transport, it is socat if it is installed, otherwise socat in a container (alpine/socat) if docker is installed.
+// To run it in a container (with compose for example), the image needs to have socat or docker installed.
+func MCPBridgeCommand(transport string) (*exec.Cmd, error) {
+	socatCommand := exec.Command(
+		"socat",
+		"STDIO",
+		"TCP:host.docker.internal:8811",
+	)
+	dockerCommand := exec.Command(
+		"docker",
+		"run",
+		"-i",
+		"--rm",
+		"alpine/socat",
+		"STDIO",
+		"TCP:host.docker.internal:8811",
+	)
+
+	switch transport {
+	case "socat":
+		if _, err := exec.LookPath("socat"); err != nil {
+			return nil, fmt.Errorf("😡 socat not found in PATH: install it or use MCP_TRANSPORT=docker or MCP_TRANSPORT=http")
+		}
+		return socatCommand, nil
+	case "docker":
+		if _, err := exec.LookPath("docker"); err != nil {
+			return nil, fmt.Errorf("😡 docker not found in PATH: install it or use MCP_TRANSPORT=socat or MCP_TRANSPORT=http")
+		}
+		return dockerCommand, nil
+	case "":
+		if _, err := exec.LookPath("socat"); err == nil {
+			return socatCommand, nil
+		}
+		if _, err := exec.LookPath("docker"); err == nil {
+			return dockerCommand, nil
+		}
+		return nil, fmt.Errorf("😡 socat not found in PATH (and docker neither to run alpine/socat): install socat or docker, or use MCP_TRANSPORT=http")
+	default:
+		return nil, fmt.Errorf("😡 unknown MCP_TRANSPORT %q (socat, docker or http)", transport)
+	}
+}
+
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// TruncateToolResult shortens a tool result to at most maxChars characters (plus the marker),
+// cutting at the end of a sentence or of a word when possible, and appends a "[truncated]" marker.
+func TruncateToolResult(text string, maxChars int) string {
+	runes := []rune(text)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return text
+	}
+	truncated := string(runes[:maxChars])
+
+	// Prefer a sentence boundary, then a word boundary, in the second half of the kept text
+	cut := -1
+	for _, boundary := range []string{". ", ".\n", "\n"} {
+		if index := strings.LastIndex(truncated, boundary); index >= 0 && index+1 > cut {
+			cut = index + 1
+		}
+	}
+	if cut < len(truncated)/2 {
+		cut = strings.LastIndexAny(truncated, " \t\n")
+	}
+	if cut > len(truncated)/2 {
+		truncated = truncated[:cut]
+	}
+	return strings.TrimSpace(truncated) + " [truncated]"
+}
+
+// ValidateToolArgs checks the arguments of a tool call against the input schema of the MCP tool:
+// the required properties must be present and the properties must have the type given by the schema.
+// It avoids sending obviously bad calls to the MCP server.
+func ValidateToolArgs(tool mcp_golang.ToolRetType, args map[string]any) error {
+	schema, ok := tool.InputSchema.(map[string]any)
+	if !ok {
+		return nil
+	}
+	properties, _ := schema["properties"].(map[string]any)
+
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := args[fmt.Sprint(name)]; !ok {
+			return fmt.Errorf("%s: missing required argument %s", tool.Name, name)
+		}
+	}
+
+	for name, value := range args {
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		expectedType, _ := property["type"].(string)
+		if expectedType != "" && !hasJSONType(value, expectedType) {
+			return fmt.Errorf("%s: argument %s must be of type %s, got %v", tool.Name, name, expectedType, value)
+		}
+	}
+	return nil
+}
+
+// hasJSONType reports whether the value decoded from JSON has the JSON schema type
+func hasJSONType(value any, jsonType string) bool {
+	switch jsonType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
 func ConvertToOpenAITools(tools []mcp_golang.ToolRetType) []openai.ChatCompletionToolParam {
 	openAITools := make([]openai.ChatCompletionToolParam, len(tools))
 
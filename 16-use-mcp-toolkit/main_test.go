@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+func TestValidateToolArgs(t *testing.T) {
+	// the input schema as decoded from the MCP server response
+	var schema any
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"url": {"type": "string"},
+			"max_length": {"type": "integer"},
+			"temperature": {"type": "number"}
+		},
+		"required": ["url"]
+	}`), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := mcp_golang.ToolRetType{Name: "fetch", InputSchema: schema}
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{"valid", `{"url": "https://docker.com", "max_length": 500, "temperature": 0.5}`, ""},
+		{"missing required argument", `{"max_length": 500}`, "missing required argument url"},
+		{"wrong type", `{"url": 42}`, "argument url must be of type string"},
+		{"integer with a fraction", `{"url": "https://docker.com", "max_length": 500.5}`, "argument max_length must be of type integer"},
+		{"integer as a number", `{"url": "https://docker.com", "temperature": 1}`, ""},
+		{"unknown argument", `{"url": "https://docker.com", "format": "markdown"}`, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var args map[string]any
+			if err := json.Unmarshal([]byte(test.args), &args); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateToolArgs(tool, args)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -4,11 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
+	"time"
 
 	mcp_golang "github.com/metoro-io/mcp-golang"
+	mcp_http "github.com/metoro-io/mcp-golang/transport/http"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
 
 	"github.com/openai/openai-go"
@@ -21,7 +27,7 @@ func main() {
 	ctx := context.Background()
 
 	// Docker Model Runner base URL
-	chatURL := os.Getenv("MODEL_RUNNER_BASE_URL") + "/engines/llama.cpp/v1/"
+	chatURL := strings.TrimRight(os.Getenv("MODEL_RUNNER_BASE_URL"), "/") + "/engines/llama.cpp/v1/"
 	modelTools := os.Getenv("MODEL_RUNNER_LLM_TOOLS")
 	modelChat := os.Getenv("MODEL_RUNNER_LLM_CHAT")
 
@@ -43,13 +49,24 @@ func main() {
 		The output format MUST be in markdown.
 	`
 
-	// Create a new MCP client
-	mcpClient, cmd, err := GetMCPClient(ctx)
+	// MCP_INIT_TIMEOUT is optional (ex: 10s)
+	mcpInitTimeout := 30 * time.Second
+	if value := os.Getenv("MCP_INIT_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("😡 Invalid MCP_INIT_TIMEOUT: %v", err)
+		}
+		mcpInitTimeout = timeout
+	}
 
+	// Create a new MCP client (MCP_TRANSPORT is optional: socat, docker or http)
+	mcpClient, cmd, err := GetMCPClient(ctx, mcpInitTimeout)
 	if err != nil {
 		log.Fatalf("😡 Failed to create MCP client: %v", err)
 	}
-	defer cmd.Process.Kill()
+	if cmd != nil {
+		defer cmd.Process.Kill()
+	}
 
 	// Get the list of the available MCP tools
 	mcpTools, err := mcpClient.ListTools(ctx, nil)
@@ -62,9 +79,12 @@ func main() {
 	fmt.Println("⏳ Filtering tools...")
 
 	filteredTools := []mcp_golang.ToolRetType{}
+	// the MCP tools by name, to validate the arguments of the tool calls
+	mcpToolsByName := map[string]mcp_golang.ToolRetType{}
 	for _, tool := range mcpTools.Tools {
 		if tool.Name == "brave_web_search" || tool.Name == "fetch" { //|| tool.Name == "fetch"
 			filteredTools = append(filteredTools, tool)
+			mcpToolsByName[tool.Name] = tool
 		}
 	}
 
@@ -76,6 +96,26 @@ func main() {
 		//fmt.Println("🛠️  Description: ", tool.Function.Description)
 	}
 
+	// TOOL_RESULT_MAX_LENGTH is optional: the longer tool responses are summarized (ex: 4000)
+	toolResultMaxLength := 0
+	if value := os.Getenv("TOOL_RESULT_MAX_LENGTH"); value != "" {
+		maxLength, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatalf("😡 Invalid TOOL_RESULT_MAX_LENGTH: %v", err)
+		}
+		toolResultMaxLength = maxLength
+	}
+
+	// TOOL_RESULT_MAX_CHARS is optional: the longer tool responses are truncated (ex: 8000)
+	toolResultMaxChars := 0
+	if value := os.Getenv("TOOL_RESULT_MAX_CHARS"); value != "" {
+		maxChars, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatalf("😡 Invalid TOOL_RESULT_MAX_CHARS: %v", err)
+		}
+		toolResultMaxChars = maxChars
+	}
+
 	// Create a list of messages for the tools and chat completion requests
 	messages := []openai.ChatCompletionMessageParamUnion{
 		openai.SystemMessage(systemInstructions),
@@ -120,6 +160,17 @@ func main() {
 
 			fmt.Println("📣 calling ", toolCall.Function.Name, toolCall.Function.Arguments)
 
+			// reject the tools invented by the model
+			tool, ok := mcpToolsByName[toolCall.Function.Name]
+			if !ok {
+				log.Println("❌😡 Unknown tool:", toolCall.Function.Name)
+				continue
+			}
+			if err := ValidateToolArgs(tool, args); err != nil {
+				log.Println("❌😡 Invalid tool arguments:", err)
+				continue
+			}
+
 			// Call the tool with the arguments
 			toolResponse, err := mcpClient.CallTool(ctx, toolCall.Function.Name, args)
 			if err != nil {
@@ -127,16 +178,32 @@ func main() {
 				continue
 			}
 
+			toolResult := toolResponse.Content[0].TextContent.Text
+
+			// Summarize the long tool results (ex: web pages) to stay under the context limit
+			if toolResultMaxLength > 0 && len(toolResult) > toolResultMaxLength {
+				fmt.Println("📝 Summarizing the tool response of", len(toolResult), "characters...")
+				summary, err := SummarizeToolResult(ctx, dmrClient, modelChat, toolResult, toolResultMaxLength)
+				if err != nil {
+					log.Println("❌😡 Failed to summarize tool response:", err)
+				} else {
+					toolResult = summary
+				}
+			}
+
+			// Cut the tool results still too long
+			toolResult = TruncateToolResult(toolResult, toolResultMaxChars)
+
 			// Create a proper tool response message
 			toolMessages = append(
 				toolMessages,
 				openai.ToolMessage(
-					toolResponse.Content[0].TextContent.Text,
+					toolResult,
 					toolCall.ID,
 				),
 			)
 
-			fmt.Println("📝 Tool response:\n", toolResponse.Content[0].TextContent.Text)
+			fmt.Println("📝 Tool response:\n", toolResult)
 		}
 
 		// Add all tool messages at once
@@ -158,50 +225,219 @@ func main() {
 
 	fmt.Println("🎉 tools execution completed.")
 
-	// only for ai/qwen3:latest
-	messages = append(messages, openai.SystemMessage("/no_think"))
+	// SHOW_REASONING=true displays the reasoning of the model before the report
+	showReasoning, _ := strconv.ParseBool(os.Getenv("SHOW_REASONING"))
+	// THINKING=true lets the thinking models (ai/qwen3) think before answering (needed to show the reasoning)
+	thinking, _ := strconv.ParseBool(os.Getenv("THINKING"))
+	messages = AddThinkingDirective(messages, modelChat, thinking || showReasoning)
 		
+	// The tools were called: the model must answer without calling them again
 	params := openai.ChatCompletionNewParams{
 		Messages:    messages,
 		Model:       modelChat,
 		Temperature: openai.Opt(0.9),
+		Tools:       openAITools,
+		ToolChoice: openai.ChatCompletionToolChoiceOptionUnionParam{
+			OfAuto: openai.String("none"),
+		},
 	}
 
 	stream := dmrClient.Chat.Completions.NewStreaming(ctx, params)
 
+	// The <think> blocks are removed from the report (and displayed only with SHOW_REASONING)
+	reasoningStarted, reportStarted := false, false
+	var reasoning io.Writer
+	if showReasoning {
+		reasoning = writerFunc(func(p []byte) (int, error) {
+			if !reasoningStarted {
+				fmt.Println("🧠 Reasoning:")
+				reasoningStarted = true
+			}
+			return os.Stdout.Write(p)
+		})
+	}
+	report := writerFunc(func(p []byte) (int, error) {
+		text := string(p)
+		if !reportStarted {
+			text = strings.TrimLeft(text, " \t\n")
+			if text == "" {
+				return len(p), nil
+			}
+			if reasoningStarted {
+				fmt.Println()
+				fmt.Println()
+				fmt.Println("📝 Report:")
+			}
+			reportStarted = true
+		}
+		fmt.Print(text)
+		return len(p), nil
+	})
+	splitter := NewThinkSplitter(report, reasoning)
+
 	for stream.Next() {
 		chunk := stream.Current()
 		// Stream each chunk as it arrives
 		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
-			fmt.Print(chunk.Choices[0].Delta.Content)
+			io.WriteString(splitter, chunk.Choices[0].Delta.Content)
 		}
 	}
+	splitter.Flush()
+
+}
+
+// IsThinkingModel reports whether the model is a Qwen3 family model,
+// those models think (<think> block) before answering unless they get the /no_think directive
+func IsThinkingModel(model string) bool {
+	return strings.Contains(strings.ToLower(model), "qwen3")
+}
+
+// AddThinkingDirective appends the /think or /no_think system message for the thinking models
+// (see IsThinkingModel), the other models get the messages unchanged
+func AddThinkingDirective(messages []openai.ChatCompletionMessageParamUnion, model string, thinking bool) []openai.ChatCompletionMessageParamUnion {
+	if !IsThinkingModel(model) {
+		return messages
+	}
+	if thinking {
+		return append(messages, openai.SystemMessage("/think"))
+	}
+	return append(messages, openai.SystemMessage("/no_think"))
+}
 
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// ThinkSplitter is a writer separating the reasoning of the model (inside <think>...</think>, Qwen models)
+// from the answer in a streamed content: the reasoning goes to one writer and the answer to the other.
+// The tags can be split across several writes.
+type ThinkSplitter struct {
+	content   io.Writer
+	reasoning io.Writer
+
+	contentText   strings.Builder
+	reasoningText strings.Builder
+	inThink       bool
+	// pending is the end of the last write that may be the beginning of a tag
+	pending string
 }
 
-func GetMCPClient(ctx context.Context) (*mcp_golang.Client, *exec.Cmd, error) {
+// NewThinkSplitter creates a ThinkSplitter writing the answer to content and the reasoning to reasoning
+// (a nil writer discards the text)
+func NewThinkSplitter(content, reasoning io.Writer) *ThinkSplitter {
+	if content == nil {
+		content = io.Discard
+	}
+	if reasoning == nil {
+		reasoning = io.Discard
+	}
+	return &ThinkSplitter{content: content, reasoning: reasoning}
+}
 
-	/*
-		cmd := exec.Command(
-			"docker",
-			"run",
-			"-i",
-			"--rm",
-			"alpine/socat",
-			"STDIO",
-			"TCP:host.docker.internal:8811",
-		)
-	*/
+// Write splits the text between the answer and the reasoning
+func (s *ThinkSplitter) Write(p []byte) (int, error) {
+	text := s.pending + string(p)
+	s.pending = ""
 
-	// To run it in a container (with compose for example), the image needs to have docker installed
+	for {
+		tag := thinkOpenTag
+		if s.inThink {
+			tag = thinkCloseTag
+		}
+		if index := strings.Index(text, tag); index >= 0 {
+			if err := s.emit(text[:index]); err != nil {
+				return len(p), err
+			}
+			text = text[index+len(tag):]
+			s.inThink = !s.inThink
+			continue
+		}
+		// keep the end of the text if it may be the beginning of the tag
+		keep := partialTagLength(text, tag)
+		s.pending = text[len(text)-keep:]
+		return len(p), s.emit(text[:len(text)-keep])
+	}
+}
 
-	// Start the MCP server process
+// Flush writes the text kept while waiting for the end of a tag
+func (s *ThinkSplitter) Flush() error {
+	text := s.pending
+	s.pending = ""
+	return s.emit(text)
+}
 
-	cmd := exec.Command(
-		"socat",
-		"STDIO",
-		"TCP:host.docker.internal:8811",
-	)
+// Content returns the answer without the reasoning
+func (s *ThinkSplitter) Content() string {
+	return strings.TrimSpace(s.contentText.String())
+}
+
+// Reasoning returns the text of the <think> blocks
+func (s *ThinkSplitter) Reasoning() string {
+	return strings.TrimSpace(s.reasoningText.String())
+}
+
+// emit writes the text to the reasoning or to the answer depending on the current block
+func (s *ThinkSplitter) emit(text string) error {
+	if text == "" {
+		return nil
+	}
+	if s.inThink {
+		s.reasoningText.WriteString(text)
+		_, err := io.WriteString(s.reasoning, text)
+		return err
+	}
+	s.contentText.WriteString(text)
+	_, err := io.WriteString(s.content, text)
+	return err
+}
+
+// partialTagLength returns the length of the longest end of the text that is the beginning of the tag
+func partialTagLength(text, tag string) int {
+	for length := min(len(text), len(tag)-1); length > 0; length-- {
+		if strings.HasSuffix(text, tag[:length]) {
+			return length
+		}
+	}
+	return 0
+}
+
+// writerFunc adapts a function to an io.Writer
+type writerFunc func(p []byte) (int, error)
+
+// Write calls the function
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// GetMCPClient connects to the MCP gateway with the transport selected by MCP_TRANSPORT and initializes the MCP client:
+//   - socat: bridge STDIO to the gateway with socat
+//   - docker: bridge STDIO to the gateway with socat in a container (alpine/socat)
+//   - http: HTTP transport to MCP_HTTP_BASE_URL (default http://host.docker.internal:8811) and MCP_HTTP_ENDPOINT (default /mcp)
+//   - unset: socat if it is installed, otherwise docker
+//
+// The command is nil with the http transport.
+// It fails if the bridge exits or if the MCP handshake does not complete within initTimeout.
+func GetMCPClient(ctx context.Context, initTimeout time.Duration) (*mcp_golang.Client, *exec.Cmd, error) {
+	transport := os.Getenv("MCP_TRANSPORT")
+
+	if transport == "http" {
+		clientTransport := mcp_http.NewHTTPClientTransport(getEnv("MCP_HTTP_ENDPOINT", "/mcp"))
+		clientTransport.WithBaseURL(getEnv("MCP_HTTP_BASE_URL", "http://host.docker.internal:8811"))
+
+		// Create a new MCP client
+		mcpClient := mcp_golang.NewClient(clientTransport)
+		if err := InitializeMCPClient(ctx, mcpClient, nil, initTimeout); err != nil {
+			return nil, nil, err
+		}
+		return mcpClient, nil, nil
+	}
+
+	// Start the MCP server process
+	cmd, err := MCPBridgeCommand(transport)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -214,21 +450,207 @@ func GetMCPClient(ctx context.Context) (*mcp_golang.Client, *exec.Cmd, error) {
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, nil, fmt.Errorf("😡 failed to start server: %v", err)
+		return nil, nil, fmt.Errorf("😡 couldn't start %s: %v", cmd.Path, err)
 	}
 
+	// socat exits when it can't reach the MCP gateway
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
 	clientTransport := stdio.NewStdioServerTransportWithIO(stdout, stdin)
 
 	// Create a new MCP client
 	mcpClient := mcp_golang.NewClient(clientTransport)
 
-	if _, err := mcpClient.Initialize(ctx); err != nil {
-		return nil, nil, fmt.Errorf("😡 failed to initialize client: %v", err)
+	if err := InitializeMCPClient(ctx, mcpClient, exited, initTimeout); err != nil {
+		cmd.Process.Kill()
+		return nil, nil, err
 	}
-
 	return mcpClient, cmd, nil
 }
 
+// InitializeMCPClient makes the MCP handshake.
+// It fails if the bridge exits (exited is nil without bridge) or if the handshake does not complete within initTimeout.
+func InitializeMCPClient(ctx context.Context, mcpClient *mcp_golang.Client, exited <-chan error, initTimeout time.Duration) error {
+	initialized := make(chan error, 1)
+	go func() {
+		_, err := mcpClient.Initialize(ctx)
+		initialized <- err
+	}()
+
+	select {
+	case err := <-initialized:
+		if err != nil {
+			return fmt.Errorf("😡 failed to initialize client: %v", err)
+		}
+		return nil
+	case err := <-exited:
+		return fmt.Errorf("😡 socat exited before the MCP handshake (is the MCP gateway listening on host.docker.internal:8811?): %v", err)
+	case <-time.After(initTimeout):
+		return fmt.Errorf("😡 MCP handshake timed out after %s (is the MCP gateway running?)", initTimeout)
+	case <-ctx.Done():
+		return fmt.Errorf("😡 MCP initialization canceled: %v", ctx.Err())
+	}
+}
+
+// MCPBridgeCommand returns the command bridging STDIO to the MCP gateway for the transport (socat or docker).
+// Without transport, it is socat if it is installed, otherwise socat in a container (alpine/socat) if docker is installed.
+// To run it in a container (with compose for example), the image needs to have socat or docker installed.
+func MCPBridgeCommand(transport string) (*exec.Cmd, error) {
+	socatCommand := exec.Command(
+		"socat",
+		"STDIO",
+		"TCP:host.docker.internal:8811",
+	)
+	dockerCommand := exec.Command(
+		"docker",
+		"run",
+		"-i",
+		"--rm",
+		"alpine/socat",
+		"STDIO",
+		"TCP:host.docker.internal:8811",
+	)
+
+	switch transport {
+	case "socat":
+		if _, err := exec.LookPath("socat"); err != nil {
+			return nil, fmt.Errorf("😡 socat not found in PATH: install it or use MCP_TRANSPORT=docker or MCP_TRANSPORT=http")
+		}
+		return socatCommand, nil
+	case "docker":
+		if _, err := exec.LookPath("docker"); err != nil {
+			return nil, fmt.Errorf("😡 docker not found in PATH: install it or use MCP_TRANSPORT=socat or MCP_TRANSPORT=http")
+		}
+		return dockerCommand, nil
+	case "":
+		if _, err := exec.LookPath("socat"); err == nil {
+			return socatCommand, nil
+		}
+		if _, err := exec.LookPath("docker"); err == nil {
+			return dockerCommand, nil
+		}
+		return nil, fmt.Errorf("😡 socat not found in PATH (and docker neither to run alpine/socat): install socat or docker, or use MCP_TRANSPORT=http")
+	default:
+		return nil, fmt.Errorf("😡 unknown MCP_TRANSPORT %q (socat, docker or http)", transport)
+	}
+}
+
+// getEnv returns the value of the environment variable or the fallback if it is not set
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// SummarizeToolResult asks the model to summarize a tool result in less than maxLength characters,
+// keeping the facts, names and URLs
+func SummarizeToolResult(ctx context.Context, client openai.Client, model string, toolResult string, maxLength int) (string, error) {
+	completion, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(fmt.Sprintf(
+				"Summarize the following text in less than %d characters. Keep the facts, the names and the URLs.",
+				maxLength,
+			)),
+			openai.UserMessage(toolResult),
+		},
+		Model:       model,
+		Temperature: openai.Opt(0.0),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// TruncateToolResult shortens a tool result to at most maxChars characters (plus the marker),
+// cutting at the end of a sentence or of a word when possible, and appends a "[truncated]" marker.
+func TruncateToolResult(text string, maxChars int) string {
+	runes := []rune(text)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return text
+	}
+	truncated := string(runes[:maxChars])
+
+	// Prefer a sentence boundary, then a word boundary, in the second half of the kept text
+	cut := -1
+	for _, boundary := range []string{". ", ".\n", "\n"} {
+		if index := strings.LastIndex(truncated, boundary); index >= 0 && index+1 > cut {
+			cut = index + 1
+		}
+	}
+	if cut < len(truncated)/2 {
+		cut = strings.LastIndexAny(truncated, " \t\n")
+	}
+	if cut > len(truncated)/2 {
+		truncated = truncated[:cut]
+	}
+	return strings.TrimSpace(truncated) + " [truncated]"
+}
+
+// ValidateToolArgs checks the arguments of a tool call against the input schema of the MCP tool:
+// the required properties must be present and the properties must have the type given by the schema.
+// It avoids sending obviously bad calls to the MCP server.
+func ValidateToolArgs(tool mcp_golang.ToolRetType, args map[string]any) error {
+	schema, ok := tool.InputSchema.(map[string]any)
+	if !ok {
+		return nil
+	}
+	properties, _ := schema["properties"].(map[string]any)
+
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := args[fmt.Sprint(name)]; !ok {
+			return fmt.Errorf("%s: missing required argument %s", tool.Name, name)
+		}
+	}
+
+	for name, value := range args {
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		expectedType, _ := property["type"].(string)
+		if expectedType != "" && !hasJSONType(value, expectedType) {
+			return fmt.Errorf("%s: argument %s must be of type %s, got %v", tool.Name, name, expectedType, value)
+		}
+	}
+	return nil
+}
+
+// hasJSONType reports whether the value decoded from JSON has the JSON schema type
+func hasJSONType(value any, jsonType string) bool {
+	switch jsonType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
 func ConvertToOpenAITools(tools []mcp_golang.ToolRetType) []openai.ChatCompletionToolParam {
 	openAITools := make([]openai.ChatCompletionToolParam, len(tools))
 
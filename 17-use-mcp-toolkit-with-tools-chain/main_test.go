@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+func TestValidateToolArgs(t *testing.T) {
+	// the input schema as decoded from the MCP server response
+	var schema any
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"url": {"type": "string"},
+			"max_length": {"type": "integer"},
+			"temperature": {"type": "number"}
+		},
+		"required": ["url"]
+	}`), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := mcp_golang.ToolRetType{Name: "fetch", InputSchema: schema}
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{"valid", `{"url": "https://docker.com", "max_length": 500, "temperature": 0.5}`, ""},
+		{"missing required argument", `{"max_length": 500}`, "missing required argument url"},
+		{"wrong type", `{"url": 42}`, "argument url must be of type string"},
+		{"integer with a fraction", `{"url": "https://docker.com", "max_length": 500.5}`, "argument max_length must be of type integer"},
+		{"integer as a number", `{"url": "https://docker.com", "temperature": 1}`, ""},
+		{"unknown argument", `{"url": "https://docker.com", "format": "markdown"}`, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var args map[string]any
+			if err := json.Unmarshal([]byte(test.args), &args); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateToolArgs(tool, args)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestThinkSplitter(t *testing.T) {
+	tests := []struct {
+		name          string
+		chunks        []string
+		wantContent   string
+		wantReasoning string
+	}{
+		{"no reasoning", []string{"Hello ", "world"}, "Hello world", ""},
+		{"tags in one chunk", []string{"<think>hmm</think>Hello"}, "Hello", "hmm"},
+		{"open tag split", []string{"<th", "ink>hmm</think>Hello"}, "Hello", "hmm"},
+		{"close tag split", []string{"<think>hmm</", "thi", "nk>Hello"}, "Hello", "hmm"},
+		{"tag split in single characters", strings.Split("<think>hmm</think>Hello", ""), "Hello", "hmm"},
+		{"not a tag", []string{"1 <", " 2 <th"}, "1 < 2 <th", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var content, reasoning strings.Builder
+			splitter := NewThinkSplitter(&content, &reasoning)
+			for _, chunk := range test.chunks {
+				if _, err := io.WriteString(splitter, chunk); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := splitter.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if content.String() != test.wantContent || splitter.Content() != test.wantContent {
+				t.Errorf("content = %q, want %q", content.String(), test.wantContent)
+			}
+			if reasoning.String() != test.wantReasoning || splitter.Reasoning() != test.wantReasoning {
+				t.Errorf("reasoning = %q, want %q", reasoning.String(), test.wantReasoning)
+			}
+		})
+	}
+}